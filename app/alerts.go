@@ -0,0 +1,1353 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golf-teetimes/platforms"
+)
+
+func metroForCourse(course string) string {
+	if c, ok := platforms.FindCourse(course); ok {
+		return c.Metro
+	}
+	return ""
+}
+
+func fetchForCourse(course string, date string) ([]platforms.DisplayTeeTime, error) {
+	if c, ok := platforms.FindCourse(course); ok {
+		return c.Fetch(date)
+	}
+	// Default to Denver
+	return platforms.FetchMemberSports(platforms.MemberSportsCourses["denver"], date)
+}
+
+func bookingURLForCourse(course string) string {
+	if c, ok := platforms.FindCourse(course); ok {
+		return c.BookingURL
+	}
+	return platforms.MemberSportsCourses["denver"].BookingURL
+}
+
+func parseTimeToMinutes(timeStr string) int {
+	var parts []string = strings.Split(timeStr, " ")
+	if len(parts) != 2 {
+		return 0
+	}
+	var period string = strings.ToUpper(parts[1])
+	var timeParts []string = strings.Split(parts[0], ":")
+	if len(timeParts) != 2 {
+		return 0
+	}
+
+	var hours int
+	var mins int
+	fmt.Sscanf(timeParts[0], "%d", &hours)
+	fmt.Sscanf(timeParts[1], "%d", &mins)
+
+	if period == "PM" && hours != 12 {
+		hours = hours + 12
+	}
+	if period == "AM" && hours == 12 {
+		hours = 0
+	}
+
+	return hours*60 + mins
+}
+
+func getBaseCourse(name string) string {
+	return platforms.GetBaseCourse(name)
+}
+
+// defaultBookingWindowDays is how far ahead recurring alerts are expanded
+// when neither the alert nor the course sets a window.
+const defaultBookingWindowDays = 14
+
+// bookingWindowDays is the furthest ahead any of the alert's courses
+// releases tee times.
+func bookingWindowDays(a platforms.Alert) int {
+	var days int
+	for _, e := range alertEntries(a) {
+		if e.Window != nil && e.Window.DaysAhead > days {
+			days = e.Window.DaysAhead
+		}
+	}
+	if days == 0 {
+		return defaultBookingWindowDays
+	}
+	return days
+}
+
+// Checker cadence. Dates a course hasn't released yet are skipped until
+// releaseLead before the release moment; from then until releaseHotPeriod
+// after it the course is polled every hotCheckInterval.
+const (
+	checkInterval    = 1 * time.Minute
+	hotCheckInterval = 15 * time.Second
+	releaseLead      = 10 * time.Minute
+	releaseHotPeriod = 15 * time.Minute
+)
+
+// releaseState reports whether a course's tee times for date aren't on sale
+// yet (deferred) or are being released right about now (hot).
+func releaseState(e *platforms.CourseEntry, date string, now time.Time) (deferred bool, hot bool) {
+	release, ok := e.ReleaseAt(date)
+	if !ok {
+		return false, false
+	}
+	if now.Before(release.Add(-releaseLead)) {
+		return true, false
+	}
+	return false, now.Before(release.Add(releaseHotPeriod))
+}
+
+// alertOccurrences returns the dates the checker should look at for an alert:
+// its single Date, or for recurring alerts every matching weekday from today
+// through the booking window (or DaysAhead, or EndDate if sooner). Dates that
+// were already notified are left out.
+func alertOccurrences(a platforms.Alert, now time.Time) []string {
+	var notified map[string]bool = make(map[string]bool)
+	for _, d := range a.NotifiedDates {
+		notified[d] = true
+	}
+
+	var today time.Time = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	if a.Recurrence == nil {
+		if a.DateTo == "" {
+			if a.Date == "" || notified[a.Date] {
+				return nil
+			}
+			return []string{a.Date}
+		}
+		// Date range — every day from Date (or today) through DateTo
+		var dates []string
+		var start time.Time
+		var err error
+		start, err = time.ParseInLocation("2006-01-02", a.Date, now.Location())
+		if err != nil {
+			return nil
+		}
+		if start.Before(today) {
+			start = today
+		}
+		for d := start; d.Format("2006-01-02") <= a.DateTo; d = d.AddDate(0, 0, 1) {
+			var date string = d.Format("2006-01-02")
+			if !notified[date] {
+				dates = append(dates, date)
+			}
+		}
+		return dates
+	}
+
+	var days map[time.Weekday]bool = make(map[time.Weekday]bool)
+	for _, d := range a.Recurrence.Days {
+		days[time.Weekday(d)] = true
+	}
+
+	var window int = bookingWindowDays(a)
+	if a.Recurrence.DaysAhead > 0 && a.Recurrence.DaysAhead < window {
+		window = a.Recurrence.DaysAhead
+	}
+
+	var dates []string
+	for i := 0; i <= window; i++ {
+		var d time.Time = today.AddDate(0, 0, i)
+		var date string = d.Format("2006-01-02")
+		if a.Recurrence.EndDate != "" && date > a.Recurrence.EndDate {
+			break
+		}
+		if days[d.Weekday()] && !notified[date] {
+			dates = append(dates, date)
+		}
+	}
+	return dates
+}
+
+// maxAlertRangeDays caps how many days a Date–DateTo alert may span.
+const maxAlertRangeDays = 14
+
+// alertMetros returns the metros an alert's tee times come from.
+func alertMetros(a platforms.Alert) []string {
+	if a.Metro != "" {
+		return []string{a.Metro}
+	}
+	if len(a.CourseKeys) > 0 {
+		var seen map[string]bool = make(map[string]bool)
+		var metros []string
+		for _, key := range a.CourseKeys {
+			if c, ok := platforms.FindCourseByKey(key); ok && !seen[c.Metro] {
+				seen[c.Metro] = true
+				metros = append(metros, c.Metro)
+			}
+		}
+		return metros
+	}
+	if metro := metroForCourse(a.Course); metro != "" {
+		return []string{metro}
+	}
+	return nil
+}
+
+// checkerFetchWorkers bounds how many course requests the checker has in
+// flight at once for one group; checkerGroupWorkers bounds how many
+// metro:date groups it fetches at once.
+const (
+	checkerFetchWorkers = 6
+	checkerGroupWorkers = 3
+)
+
+// alertCities returns the cities a city-scoped alert covers.
+func alertCities(a platforms.Alert) []string {
+	if len(a.Cities) > 0 {
+		return a.Cities
+	}
+	if a.City != "" {
+		return []string{a.City}
+	}
+	return nil
+}
+
+// areaAlert reports whether the alert watches whole cities or a metro rather
+// than courses picked by name.
+func areaAlert(a platforms.Alert) bool {
+	return a.Course == "" && len(a.CourseKeys) == 0 && (len(alertCities(a)) > 0 || a.Metro != "")
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// alertEntries returns the enabled registry entries an alert watches.
+func alertEntries(a platforms.Alert) []*platforms.CourseEntry {
+	var entries []*platforms.CourseEntry
+	for i := range platforms.Registry {
+		var e *platforms.CourseEntry = &platforms.Registry[i]
+		if !e.Enabled {
+			continue
+		}
+		var watched bool
+		switch {
+		case a.Course != "":
+			watched = e.Match(a.Course)
+		case len(a.CourseKeys) > 0:
+			for _, key := range a.CourseKeys {
+				if e.Key == platforms.CanonicalCourseKey(key) {
+					watched = true
+				}
+			}
+		case len(alertCities(a)) > 0:
+			watched = e.Metro == a.Metro && containsFold(alertCities(a), e.City)
+		case a.Metro != "":
+			watched = e.Metro == a.Metro
+		}
+		if watched {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// alertCoversTeeTime reports whether a tee time is at one of the alert's
+// target courses. Tee times are fetched per metro, so a metro-wide alert
+// covers everything it is shown.
+func alertCoversTeeTime(a platforms.Alert, tt platforms.DisplayTeeTime) bool {
+	if a.Course != "" {
+		return getBaseCourse(tt.Course) == a.Course
+	}
+	if len(a.CourseKeys) > 0 {
+		for _, key := range a.CourseKeys {
+			if c, ok := platforms.FindCourseByKey(key); ok && (c.Match(tt.Course) || c.Match(getBaseCourse(tt.Course))) {
+				return true
+			}
+		}
+		return false
+	}
+	if cities := alertCities(a); len(cities) > 0 {
+		return containsFold(cities, tt.City)
+	}
+	return a.Metro != ""
+}
+
+// describeAlertTarget names what an alert watches, e.g. "Papago Golf Course",
+// "3 courses", "any course in Mesa", "any course in Mesa or Tempe" or "any
+// course in Phoenix".
+func describeAlertTarget(a platforms.Alert) string {
+	if a.Course != "" {
+		return a.Course
+	}
+	if len(a.CourseKeys) == 1 {
+		if c, ok := platforms.FindCourseByKey(a.CourseKeys[0]); ok && c.Name != "" {
+			return c.Name
+		}
+	}
+	if len(a.CourseKeys) > 0 {
+		return fmt.Sprintf("%d courses", len(a.CourseKeys))
+	}
+	var metroName string = a.Metro
+	if m, ok := Metros[a.Metro]; ok {
+		metroName = m.Name
+	}
+	if cities := alertCities(a); len(cities) > 0 {
+		if len(cities) > 3 {
+			return fmt.Sprintf("any course in %d %s cities", len(cities), metroName)
+		}
+		return "any course in " + strings.Join(cities, " or ")
+	}
+	return "any course in " + metroName
+}
+
+func validateAlertTarget(a platforms.Alert) error {
+	if a.Course != "" {
+		if _, ok := platforms.FindCourse(a.Course); !ok {
+			return errors.New("Unknown course: " + a.Course)
+		}
+		return nil
+	}
+	if len(a.CourseKeys) > 0 {
+		for _, key := range a.CourseKeys {
+			if _, ok := platforms.FindCourseByKey(key); !ok {
+				return errors.New("Unknown course: " + key)
+			}
+		}
+		return nil
+	}
+	if a.Metro == "" {
+		return errors.New("Pick a course, a list of courses, or a metro.")
+	}
+	if _, ok := Metros[a.Metro]; !ok {
+		return errors.New("Unknown metro: " + a.Metro)
+	}
+	for _, city := range alertCities(a) {
+		var known bool
+		for i := range platforms.Registry {
+			if platforms.Registry[i].Metro == a.Metro && strings.EqualFold(platforms.Registry[i].City, city) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return errors.New("No courses we track in " + city + ".")
+		}
+	}
+	return nil
+}
+
+func validateDateRange(from string, to string) error {
+	var start time.Time
+	var end time.Time
+	var err error
+	start, err = time.Parse("2006-01-02", from)
+	if err != nil {
+		return errors.New("Invalid start date.")
+	}
+	end, err = time.Parse("2006-01-02", to)
+	if err != nil {
+		return errors.New("Invalid end date.")
+	}
+	if end.Before(start) {
+		return errors.New("End date must be on or after the start date.")
+	}
+	if end.Sub(start) > maxAlertRangeDays*24*time.Hour {
+		return fmt.Errorf("Date ranges can span at most %d days.", maxAlertRangeDays)
+	}
+	return nil
+}
+
+// sameAlertTarget reports whether two alerts watch the same courses.
+func sameAlertTarget(a platforms.Alert, b platforms.Alert) bool {
+	if a.Course != b.Course || a.Metro != b.Metro || len(a.CourseKeys) != len(b.CourseKeys) {
+		return false
+	}
+	var aCities, bCities []string = alertCities(a), alertCities(b)
+	if len(aCities) != len(bCities) {
+		return false
+	}
+	for _, c := range bCities {
+		if !containsFold(aCities, c) {
+			return false
+		}
+	}
+	var keys map[string]bool = make(map[string]bool)
+	for _, k := range a.CourseKeys {
+		keys[k] = true
+	}
+	for _, k := range b.CourseKeys {
+		if !keys[k] {
+			return false
+		}
+	}
+	return true
+}
+
+var weekdayAbbrevs = []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+
+// describeAlertDates renders an alert's schedule, e.g. "2026-05-02",
+// "2026-05-02 to 2026-05-03" or "every Sat, Sun until 2026-09-01".
+func describeAlertDates(a platforms.Alert) string {
+	if a.Recurrence == nil {
+		if a.DateTo != "" && a.DateTo != a.Date {
+			return a.Date + " to " + a.DateTo
+		}
+		return a.Date
+	}
+	var names []string
+	for _, d := range a.Recurrence.Days {
+		names = append(names, weekdayAbbrevs[d])
+	}
+	var desc string = "every " + strings.Join(names, ", ")
+	if a.Recurrence.EndDate != "" {
+		desc += " until " + a.Recurrence.EndDate
+	}
+	return desc
+}
+
+func validateRecurrence(r *platforms.Recurrence) error {
+	if len(r.Days) == 0 {
+		return errors.New("Pick at least one day of the week to repeat on.")
+	}
+	for _, d := range r.Days {
+		if d < 0 || d > 6 {
+			return errors.New("Invalid day of week.")
+		}
+	}
+	if r.EndDate != "" {
+		if _, err := time.Parse("2006-01-02", r.EndDate); err != nil {
+			return errors.New("Invalid end date.")
+		}
+	}
+	if r.DaysAhead < 0 {
+		return errors.New("Days ahead can't be negative.")
+	}
+	return nil
+}
+
+func sharesWeekday(a *platforms.Recurrence, b *platforms.Recurrence) bool {
+	for _, x := range a.Days {
+		for _, y := range b.Days {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validateAlert normalizes an incoming alert and checks it the same way for
+// creation and edits.
+func validateAlert(a *platforms.Alert) error {
+	if a.Phone != "" {
+		a.Phone = normalizePhone(a.Phone)
+	}
+	a.Channel = alertChannel(*a)
+	if err := validateChannel(a); err != nil {
+		return err
+	}
+	return validateAlertFilters(a)
+}
+
+// validateAlertFilters normalizes and checks what an alert watches for: its
+// target, dates, time window and filters, but not where it's delivered.
+func validateAlertFilters(a *platforms.Alert) error {
+	// Validate start time is before end time
+	var startMins int = parseTimeToMinutes(a.StartTime)
+	var endMins int = parseTimeToMinutes(a.EndTime)
+	if startMins >= endMins {
+		return errors.New("Start time must be before end time.")
+	}
+
+	if err := validateAlertTarget(*a); err != nil {
+		return err
+	}
+	switch a.Mode {
+	case "", platforms.AlertModeOnce:
+		a.Mode = platforms.AlertModeOnce
+	case platforms.AlertModeContinuous:
+		if a.CooldownMinutes < 0 || a.MaxNotifications < 0 {
+			return errors.New("Cooldown and notification limit can't be negative.")
+		}
+		if a.CooldownMinutes > 0 && a.CooldownMinutes < minAlertCooldownMinutes {
+			return fmt.Errorf("Cooldown must be at least %d minutes.", minAlertCooldownMinutes)
+		}
+		if a.MaxNotifications > maxAlertNotifications {
+			return fmt.Errorf("Continuous alerts can send at most %d notifications.", maxAlertNotifications)
+		}
+	default:
+		return errors.New("Unknown alert mode: " + a.Mode)
+	}
+
+	if a.MaxPrice < 0 {
+		return errors.New("Max price can't be negative.")
+	}
+	if len(alertCities(*a)) > 0 && a.Metro == "" {
+		return errors.New("A city alert needs its metro.")
+	}
+
+	if a.Recurrence != nil {
+		if err := validateRecurrence(a.Recurrence); err != nil {
+			return err
+		}
+		a.Date = ""
+		a.DateTo = ""
+	} else if a.Date == "" {
+		return errors.New("Pick a date.")
+	} else if a.DateTo != "" {
+		if err := validateDateRange(a.Date, a.DateTo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// alertPendingVerification reports whether a text alert must wait for its
+// number to be verified, and refuses numbers that replied STOP. A number
+// verified earlier only counts for a requester signed in as that number;
+// anyone else has to confirm a fresh code.
+func alertPendingVerification(a platforms.Alert, requester string) (bool, error) {
+	if alertChannel(a) != ChannelSMS {
+		return false, nil
+	}
+	rec, err := phoneStore.GetPhone(a.Phone)
+	if err != nil {
+		return false, err
+	}
+	if rec.OptedOut {
+		return false, errors.New("This number replied STOP. Text START to us to receive alerts again.")
+	}
+	return requester != a.Phone || !phoneVerified(rec, time.Now()), nil
+}
+
+// checkAlreadyAvailable refuses a single-course, single-date alert whose
+// window already has a bookable tee time. New-only alerts are exempt: they
+// wait for slots beyond the ones already open.
+func checkAlreadyAvailable(a platforms.Alert) error {
+	if a.Course == "" || a.Recurrence != nil || a.DateTo != "" || a.NewOnly {
+		return nil
+	}
+	var startMins int = parseTimeToMinutes(a.StartTime)
+	var endMins int = parseTimeToMinutes(a.EndTime)
+
+	// Read-only external fetch, kept outside any store transaction
+	var teeTimes []platforms.DisplayTeeTime
+	teeTimes, _ = fetchForCourse(a.Course, a.Date)
+	for _, tt := range teeTimes {
+		var baseCourse string = getBaseCourse(tt.Course)
+
+		if baseCourse == a.Course && tt.Openings > 0 {
+			if a.MinPlayers > 0 && tt.Openings < a.MinPlayers {
+				continue
+			}
+			if a.Holes != "" && a.Holes != "0" && tt.Holes != "" && tt.Holes != a.Holes {
+				continue
+			}
+			if a.MaxPrice > 0 && (tt.Price <= 0 || tt.Price > a.MaxPrice) {
+				continue
+			}
+			var ttMins int = parseTimeToMinutes(tt.Time)
+			if ttMins >= startMins && ttMins <= endMins {
+				return errors.New("There's already a tee time available at " + a.Course + " at " + tt.Time + " — go book it! To hear only about newly opened times, choose \"New openings only\".")
+			}
+		}
+	}
+	return nil
+}
+
+// checkDuplicateAlert refuses an alert matching one of the owner's other
+// active alerts: same target and dates, or overlapping weekdays for
+// recurring alerts.
+func checkDuplicateAlert(alert platforms.Alert, existing []platforms.Alert) error {
+	for _, e := range existing {
+		if e.ID == alert.ID || !e.Active || !sameAlertTarget(e, alert) {
+			continue
+		}
+		if alert.Recurrence == nil && e.Recurrence == nil && e.Date == alert.Date && e.DateTo == alert.DateTo {
+			return errors.New("You already have an alert set for " + describeAlertTarget(alert) + " on " + describeAlertDates(alert) + ". Edit or delete it instead.")
+		}
+		if alert.Recurrence != nil && e.Recurrence != nil && sharesWeekday(alert.Recurrence, e.Recurrence) {
+			return errors.New("You already have a repeating alert for " + describeAlertTarget(alert) + " " + describeAlertDates(e) + ". Edit or delete it instead.")
+		}
+	}
+	return nil
+}
+
+// addAlert creates an alert. requester is the owner the request is signed in
+// as, or empty.
+func addAlert(incoming platforms.Alert, requester string) (platforms.Alert, error) {
+	if err := validateAlert(&incoming); err != nil {
+		return platforms.Alert{}, err
+	}
+
+	// A phone on an email, webhook or push alert would make the number its
+	// owner without the number ever confirming a code
+	if alertChannel(incoming) != ChannelSMS && incoming.Phone != "" && incoming.Phone != requester {
+		return platforms.Alert{}, errors.New("Only text alerts can be tied to a phone number.")
+	}
+
+	// Text alerts stay pending until the number is verified
+	pending, err := alertPendingVerification(incoming, requester)
+	if err != nil {
+		return platforms.Alert{}, err
+	}
+
+	if err = checkAlreadyAvailable(incoming); err != nil {
+		return platforms.Alert{}, err
+	}
+	if err = checkActiveAlertLimit(""); err != nil {
+		return platforms.Alert{}, err
+	}
+
+	var alert platforms.Alert = platforms.Alert{
+		ID:         fmt.Sprintf("%d", time.Now().UnixNano()),
+		Phone:      incoming.Phone,
+		Channel:    incoming.Channel,
+		Contact:    incoming.Contact,
+		Course:     incoming.Course,
+		CourseKeys: incoming.CourseKeys,
+		City:       incoming.City,
+		Cities:     incoming.Cities,
+		Metro:      incoming.Metro,
+		Date:       incoming.Date,
+		DateTo:     incoming.DateTo,
+		Recurrence: incoming.Recurrence,
+		StartTime:  incoming.StartTime,
+		EndTime:    incoming.EndTime,
+		MinPlayers: incoming.MinPlayers,
+		Holes:      incoming.Holes,
+		MaxPrice:   incoming.MaxPrice,
+		Active:     !pending,
+		Pending:    pending,
+
+		Mode:             incoming.Mode,
+		CooldownMinutes:  incoming.CooldownMinutes,
+		MaxNotifications: incoming.MaxNotifications,
+		NewOnly:          incoming.NewOnly,
+
+		CreatedAt: time.Now().Format("2006-01-02 3:04 PM"),
+		ConsentAt: time.Now().Format("2006-01-02 3:04:05 PM MST"),
+		Consent:   incoming.Consent,
+	}
+
+	// Check for duplicates and the owner's limit in the insert transaction
+	err = alertStore.Create(alert, func(existing []platforms.Alert) error {
+		if err := checkOwnerAlertLimit(alert, existing); err != nil {
+			return err
+		}
+		return checkDuplicateAlert(alert, existing)
+	})
+	if err != nil {
+		return platforms.Alert{}, err
+	}
+
+	return alert, nil
+}
+
+// updateAlert applies an owner's edits to the schedule, filters and delivery
+// channel of an alert. The target courses and owner can't change. Edits are
+// validated like a new alert, and the checker's state is reset so the edited
+// alert is evaluated from scratch.
+func updateAlert(id string, owner string, changes platforms.Alert) (platforms.Alert, error) {
+	existing, err := alertStore.Get(id)
+	if err != nil {
+		return platforms.Alert{}, err
+	}
+	if alertOwner(existing) != owner {
+		return platforms.Alert{}, ErrAlertNotFound
+	}
+
+	var edited platforms.Alert = existing
+	edited.Date = changes.Date
+	edited.DateTo = changes.DateTo
+	edited.Recurrence = changes.Recurrence
+	edited.StartTime = changes.StartTime
+	edited.EndTime = changes.EndTime
+	edited.MinPlayers = changes.MinPlayers
+	edited.Holes = changes.Holes
+	edited.MaxPrice = changes.MaxPrice
+	edited.Channel = changes.Channel
+	edited.Contact = changes.Contact
+	edited.Mode = changes.Mode
+	edited.CooldownMinutes = changes.CooldownMinutes
+	edited.MaxNotifications = changes.MaxNotifications
+	edited.NewOnly = changes.NewOnly
+	if existing.Phone == "" || (edited.Channel != ChannelSMS && edited.Channel != "" && edited.Contact == "") {
+		// Phoneless alerts are owned by their contact, so it stays put
+		edited.Contact = existing.Contact
+	}
+
+	if err = validateAlert(&edited); err != nil {
+		return platforms.Alert{}, err
+	}
+	pending, err := alertPendingVerification(edited, owner)
+	if err != nil {
+		return platforms.Alert{}, err
+	}
+	if err = checkAlreadyAvailable(edited); err != nil {
+		return platforms.Alert{}, err
+	}
+	others, err := alertStore.ByPhone(owner)
+	if err != nil {
+		return platforms.Alert{}, err
+	}
+	if err = checkDuplicateAlert(edited, others); err != nil {
+		return platforms.Alert{}, err
+	}
+	// Editing a triggered alert re-arms it, so it counts toward the limits
+	if !liveAlert(existing) {
+		if err = checkOwnerAlertLimit(edited, others); err != nil {
+			return platforms.Alert{}, err
+		}
+		if err = checkActiveAlertLimit(id); err != nil {
+			return platforms.Alert{}, err
+		}
+	}
+
+	return alertStore.Update(id, func(a *platforms.Alert) error {
+		if alertOwner(*a) != owner {
+			return ErrAlertNotFound
+		}
+		var optedOut bool = a.OptedOut
+		var revision int = a.Revision
+		*a = edited
+		a.OptedOut = optedOut
+		a.Revision = revision + 1
+		a.Pending = pending
+		a.Active = !pending && !optedOut
+
+		// Start over: past notifications were for the old settings
+		a.NotifiedDates = nil
+		a.SeenTeeTimes = nil
+		a.NotificationCount = 0
+		a.LastNotifiedAt = ""
+		return nil
+	})
+}
+
+func deleteAlertByOwner(id string, phone string) error {
+	return alertStore.Delete(id, func(a platforms.Alert) error {
+		if alertOwner(a) != phone {
+			return errors.New("Not authorized to delete this alert")
+		}
+		return nil
+	})
+}
+
+func deleteAlert(id string) error {
+	return alertStore.Delete(id, nil)
+}
+
+type MatchedTeeTime struct {
+	Course   string  `json:"course"`
+	Date     string  `json:"date"`
+	Time     string  `json:"time"`
+	Openings int     `json:"openings"`
+	Price    float64 `json:"price"`
+	Holes    string  `json:"holes"`
+}
+
+// Continuous-mode defaults and limits.
+const (
+	defaultAlertCooldownMinutes = 30
+	minAlertCooldownMinutes     = 5
+	defaultAlertNotifications   = 10
+	maxAlertNotifications       = 50
+)
+
+// teeTimeKey identifies a matched tee time across checks.
+func teeTimeKey(m MatchedTeeTime) string {
+	return m.Course + "|" + m.Date + "|" + m.Time + "|" + m.Holes
+}
+
+// diffSeenTeeTimes splits this check's matches into tee times the alert
+// hasn't notified about yet and the previously seen keys that are still
+// open. Seen keys that disappeared are dropped, so a slot that is booked and
+// later released again counts as new.
+func diffSeenTeeTimes(seen []string, matches []MatchedTeeTime) ([]MatchedTeeTime, []string) {
+	var wasSeen map[string]bool = make(map[string]bool)
+	for _, k := range seen {
+		wasSeen[k] = true
+	}
+	var fresh []MatchedTeeTime
+	var still []string
+	for _, m := range matches {
+		var key string = teeTimeKey(m)
+		if wasSeen[key] {
+			still = append(still, key)
+			delete(wasSeen, key) // no duplicates
+		} else {
+			fresh = append(fresh, m)
+		}
+	}
+	return fresh, still
+}
+
+// alertCoolingDown reports whether a continuous alert notified too recently
+// to send again.
+func alertCoolingDown(a platforms.Alert, now time.Time) bool {
+	if a.LastNotifiedAt == "" {
+		return false
+	}
+	last, err := time.Parse(time.RFC3339, a.LastNotifiedAt)
+	if err != nil {
+		return false
+	}
+	var cooldown int = a.CooldownMinutes
+	if cooldown == 0 {
+		cooldown = defaultAlertCooldownMinutes
+	}
+	return now.Sub(last) < time.Duration(cooldown)*time.Minute
+}
+
+func alertNotificationLimit(a platforms.Alert) int {
+	if a.MaxNotifications == 0 {
+		return defaultAlertNotifications
+	}
+	return a.MaxNotifications
+}
+
+func sameKeys(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// markOccurrenceNotified records a sent notification. One-shot and date-range
+// alerts are deactivated; recurring alerts stay armed for their other dates.
+func markOccurrenceNotified(a *platforms.Alert, date string, today string) {
+	if a.Recurrence == nil {
+		a.Active = false
+		return
+	}
+	var kept []string
+	for _, d := range a.NotifiedDates {
+		if d >= today {
+			kept = append(kept, d)
+		}
+	}
+	a.NotifiedDates = append(kept, date)
+}
+
+// rankedAlertMatches is how many tee times a ranked message lists.
+const rankedAlertMatches = 5
+
+// buildAlertMessage renders every match from one check cycle as a single
+// message, grouped by course and date. Area alerts (see areaAlert) can match
+// dozens of courses, so their matches are ranked instead, see
+// buildRankedAlertMessage.
+func buildAlertMessage(matches []MatchedTeeTime, maxPrice float64, ranked bool) string {
+	if ranked {
+		return buildRankedAlertMessage(matches, maxPrice)
+	}
+
+	type matchGroup struct {
+		course string
+		date   string
+		times  []MatchedTeeTime
+	}
+	var groups []*matchGroup
+	var byKey map[string]*matchGroup = make(map[string]*matchGroup)
+	for _, m := range matches {
+		var key string = m.Course + "|" + m.Date
+		g, ok := byKey[key]
+		if !ok {
+			g = &matchGroup{course: m.Course, date: m.Date}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+		g.times = append(g.times, m)
+	}
+
+	var msg string
+	if len(groups) == 1 {
+		msg = "⛳ Tee time alert! " + groups[0].course + " on " + groups[0].date
+	} else {
+		msg = "⛳ Tee time alert!"
+	}
+	if maxPrice > 0 {
+		msg += fmt.Sprintf(" (up to $%.0f/player)", maxPrice)
+	}
+	if len(groups) == 1 {
+		msg += ":"
+	}
+	msg += "\n"
+
+	for i, g := range groups {
+		if len(groups) > 1 {
+			if i > 0 {
+				msg += "\n"
+			}
+			msg += g.course + " on " + g.date + ":\n"
+		}
+		for _, m := range g.times {
+			msg += fmt.Sprintf("%s (%d openings, %s holes) - %s\n", m.Time, m.Openings, m.Holes, formatAlertPrice(m.Price))
+		}
+		if len(groups) > 1 {
+			msg += "Book: " + bookingURLForCourse(g.course) + "\n"
+		}
+	}
+
+	if len(groups) == 1 {
+		msg += "\nBook now: " + bookingURLForCourse(groups[0].course)
+	}
+
+	return msg
+}
+
+// formatAlertPrice shows a tee time's price in a notification. Platforms
+// report 0 when they don't publish a price.
+func formatAlertPrice(price float64) string {
+	if price > 0 {
+		return fmt.Sprintf("$%.0f", price)
+	}
+	return "price n/a"
+}
+
+// buildRankedAlertMessage lists the cheapest and then earliest of matches
+// from several courses, with a booking link per course shown. Unknown prices
+// (0) rank last.
+func buildRankedAlertMessage(matches []MatchedTeeTime, maxPrice float64) string {
+	var ranked []MatchedTeeTime = append([]MatchedTeeTime(nil), matches...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		var pi, pj float64 = ranked[i].Price, ranked[j].Price
+		if (pi > 0) != (pj > 0) {
+			return pi > 0
+		}
+		if pi != pj {
+			return pi < pj
+		}
+		if ranked[i].Date != ranked[j].Date {
+			return ranked[i].Date < ranked[j].Date
+		}
+		return parseTimeToMinutes(ranked[i].Time) < parseTimeToMinutes(ranked[j].Time)
+	})
+	var more int
+	if len(ranked) > rankedAlertMatches {
+		more = len(ranked) - rankedAlertMatches
+		ranked = ranked[:rankedAlertMatches]
+	}
+
+	var msg string = "⛳ Tee time alert! Best matches"
+	if maxPrice > 0 {
+		msg += fmt.Sprintf(" (up to $%.0f/player)", maxPrice)
+	}
+	msg += ":\n"
+
+	var shown []string
+	var seen map[string]bool = make(map[string]bool)
+	for _, m := range ranked {
+		msg += fmt.Sprintf("%s %s %s (%d openings, %s holes) - %s\n", m.Course, m.Date, m.Time, m.Openings, m.Holes, formatAlertPrice(m.Price))
+		if !seen[m.Course] {
+			seen[m.Course] = true
+			shown = append(shown, m.Course)
+		}
+	}
+	if more > 0 {
+		msg += fmt.Sprintf("+%d more matching times\n", more)
+	}
+
+	msg += "\n"
+	for _, course := range shown {
+		msg += "Book " + course + ": " + bookingURLForCourse(course) + "\n"
+	}
+	return strings.TrimSuffix(msg, "\n")
+}
+
+// Filters a tee time at one of the alert's courses can fail, in the order
+// they're checked.
+const (
+	filterOpenings = "openings"
+	filterPlayers  = "players"
+	filterHoles    = "holes"
+	filterUnpriced = "unpriced"
+	filterPrice    = "price"
+	filterTime     = "time"
+)
+
+// teeTimeExclusion returns the filter that rules a tee time out for the
+// alert and why, or empty strings if it matches.
+func teeTimeExclusion(alert platforms.Alert, tt platforms.DisplayTeeTime, startMins int, endMins int) (string, string) {
+	if tt.Openings <= 0 {
+		return filterOpenings, "no openings"
+	}
+	if alert.MinPlayers > 0 && tt.Openings < alert.MinPlayers {
+		return filterPlayers, fmt.Sprintf("only %d openings, need %d", tt.Openings, alert.MinPlayers)
+	}
+	if alert.Holes != "" && alert.Holes != "0" && tt.Holes != "" && tt.Holes != alert.Holes {
+		return filterHoles, tt.Holes + " holes, want " + alert.Holes
+	}
+	// With a price limit, a tee time without a listed price can't be shown
+	// to be under it
+	if alert.MaxPrice > 0 && tt.Price <= 0 {
+		return filterUnpriced, fmt.Sprintf("no listed price, max $%.0f", alert.MaxPrice)
+	}
+	if alert.MaxPrice > 0 && tt.Price > alert.MaxPrice {
+		return filterPrice, fmt.Sprintf("$%.0f, max $%.0f", tt.Price, alert.MaxPrice)
+	}
+	var ttMins int = parseTimeToMinutes(tt.Time)
+	if ttMins < startMins || ttMins > endMins {
+		return filterTime, "outside time window"
+	}
+	return "", ""
+}
+
+// matchAlert returns the tee times from one metro+date fetch that satisfy
+// the alert, logging why each tee time at a target course was rejected.
+func matchAlert(alert platforms.Alert, date string, teeTimes []platforms.DisplayTeeTime) []MatchedTeeTime {
+	var startMins int = parseTimeToMinutes(alert.StartTime)
+	var endMins int = parseTimeToMinutes(alert.EndTime)
+	var matches []MatchedTeeTime
+
+	for _, tt := range teeTimes {
+		if !alertCoversTeeTime(alert, tt) {
+			continue
+		}
+
+		if _, reason := teeTimeExclusion(alert, tt, startMins, endMins); reason != "" {
+			fmt.Println("    ✗", tt.Time, tt.Course, "—", reason)
+			continue
+		}
+
+		fmt.Println("    ✓ MATCH!", tt.Time, tt.Course, "—", tt.Openings, "openings, $", tt.Price)
+		matches = append(matches, MatchedTeeTime{
+			Course:   getBaseCourse(tt.Course),
+			Date:     date,
+			Time:     tt.Time,
+			Openings: tt.Openings,
+			Price:    tt.Price,
+			Holes:    tt.Holes,
+		})
+	}
+
+	return matches
+}
+
+type alertGroup struct {
+	metro string
+	date  string
+}
+
+type groupFetch struct {
+	teeTimes []platforms.DisplayTeeTime
+	fetched  time.Time
+}
+
+// errAlertChanged aborts queueing a notification for an alert that changed
+// while the checker was matching it.
+var errAlertChanged = errors.New("alert changed during check")
+
+// checkAlerts runs one pass of the alert checker: it fetches what active
+// alerts need, queues notifications for matches and returns how long to wait
+// before the next pass. lastFetch carries each group's latest fetch between
+// passes.
+func checkAlerts(lastFetch map[alertGroup]groupFetch) time.Duration {
+	var sleep time.Duration = checkInterval
+
+	// Prune alerts whose date has passed
+	var today string = time.Now().Format("2006-01-02")
+	if pruned, err := alertStore.PruneBefore(today); err != nil {
+		fmt.Println("  [ERROR] Pruning alerts:", err)
+	} else if pruned > 0 {
+		fmt.Println("  Pruned", pruned, "expired alert(s)")
+	}
+	if _, err := slotStore.PruneSlots(time.Now().Add(-slotEventTTL)); err != nil {
+		fmt.Println("  [ERROR] Pruning slot snapshots:", err)
+	}
+	if _, err := slotStore.PruneOpeningTallies(time.Now().Add(-openingTallyTTL).Format("2006-01-02")); err != nil {
+		fmt.Println("  [ERROR] Pruning opening tallies:", err)
+	}
+
+	var alerts []platforms.Alert
+	var err error
+	alerts, err = alertStore.List()
+	if err != nil {
+		fmt.Println("  [ERROR] Loading alerts:", err)
+		return checkInterval
+	}
+
+	var activeCount int = 0
+	for _, alert := range alerts {
+		if alert.Active {
+			activeCount++
+		}
+	}
+
+	fmt.Println("")
+	fmt.Println("──────────────────────────────────────")
+	fmt.Println("  Checking alerts at", time.Now().Format("3:04:05 PM"))
+	fmt.Println("  Active alerts:", activeCount)
+	fmt.Println("──────────────────────────────────────")
+
+	if activeCount == 0 {
+		fmt.Println("  No active alerts — sleeping")
+		return checkInterval
+	}
+
+	// Collect the metro:date groups each active alert needs, and which
+	// courses in each group any alert actually watches, so every course
+	// is fetched once per date no matter how many alerts share it
+	var groups map[alertGroup]map[string]*platforms.CourseEntry = make(map[alertGroup]map[string]*platforms.CourseEntry)
+	var groupAlerts map[alertGroup]int = make(map[alertGroup]int)
+	var alertGroups map[string][]alertGroup = make(map[string][]alertGroup)
+
+	var now time.Time = time.Now()
+	for _, alert := range alerts {
+		if !alert.Active {
+			continue
+		}
+		var entries []*platforms.CourseEntry = alertEntries(alert)
+		if len(entries) == 0 {
+			// Unknown or disabled course — skip
+			fmt.Println("  [WARN] No courses found for alert:", alert.ID, describeAlertTarget(alert))
+			continue
+		}
+		// Recurring and date-range alerts fan out into one group per date
+		for _, date := range alertOccurrences(alert, now) {
+			var seen map[alertGroup]bool = make(map[alertGroup]bool)
+			for _, e := range entries {
+				var g alertGroup = alertGroup{metro: e.Metro, date: date}
+				if groups[g] == nil {
+					groups[g] = make(map[string]*platforms.CourseEntry)
+				}
+				groups[g][e.Key] = e
+				if !seen[g] {
+					seen[g] = true
+					groupAlerts[g]++
+					alertGroups[alert.ID] = append(alertGroups[alert.ID], g)
+				}
+			}
+		}
+	}
+
+	var teeTimesByGroup map[alertGroup][]platforms.DisplayTeeTime = make(map[alertGroup][]platforms.DisplayTeeTime)
+	// Slots that opened since the previous poll, by teeTimeKey
+	var newSlots map[alertGroup]map[string]bool = make(map[alertGroup]map[string]bool)
+	type groupJob struct {
+		group   alertGroup
+		entries []*platforms.CourseEntry
+		hot     bool
+	}
+	var jobs []groupJob
+	for g, needed := range groups {
+		// Leave out courses that haven't released this date yet
+		var entries []*platforms.CourseEntry
+		var deferred int
+		var hot bool
+		for _, e := range needed {
+			isDeferred, isHot := releaseState(e, g.date, now)
+			if isDeferred {
+				deferred++
+				continue
+			}
+			hot = hot || isHot
+			entries = append(entries, e)
+		}
+		if deferred > 0 {
+			fmt.Printf("  Deferring %d course(s) in %s on %s until release\n", deferred, g.metro, g.date)
+		}
+		if len(entries) == 0 {
+			continue
+		}
+
+		var interval time.Duration = checkInterval
+		if hot {
+			interval = hotCheckInterval
+			sleep = hotCheckInterval
+		}
+		if last, ok := lastFetch[g]; ok && now.Sub(last.fetched) < interval-time.Second {
+			teeTimesByGroup[g] = last.teeTimes
+			continue
+		}
+		jobs = append(jobs, groupJob{group: g, entries: entries, hot: hot})
+	}
+
+	// Fetch the due groups, checkerGroupWorkers at a time
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var sem chan struct{} = make(chan struct{}, checkerGroupWorkers)
+	for _, job := range jobs {
+		wg.Add(1)
+		go func(job groupJob) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			var g alertGroup = job.group
+			var teeTimes []platforms.DisplayTeeTime
+			var fresh map[string]bool
+
+			// Reuse what a visitor's page load already fetched, except
+			// around a release when every second counts
+			if cached, fetched, ok := cachedMetroTeeTimes(g.metro, g.date); ok && !job.hot {
+				teeTimes = cached
+				fresh = newSlotKeys(recordSlotSnapshots(job.entries, g.date, cached, fetched))
+				fmt.Printf("\n  Using %d cached tee times for %s on %s (%d alerts)\n", len(cached), g.metro, g.date, groupAlerts[g])
+			} else {
+				// Fetch only the watched courses; this partial result must
+				// not replace the metro-wide cache the tee time page serves
+				teeTimes = fetchCourseEntries(job.entries, g.date, checkerFetchWorkers)
+				fresh = newSlotKeys(recordSlotSnapshots(job.entries, g.date, teeTimes, time.Now()))
+				fmt.Printf("\n  Fetched %d tee times from %d course(s) for %s on %s (%d alerts)\n", len(teeTimes), len(job.entries), g.metro, g.date, groupAlerts[g])
+			}
+
+			mu.Lock()
+			teeTimesByGroup[g] = teeTimes
+			lastFetch[g] = groupFetch{teeTimes: teeTimes, fetched: now}
+			newSlots[g] = fresh
+			mu.Unlock()
+		}(job)
+	}
+	wg.Wait()
+	for g := range lastFetch {
+		if groups[g] == nil {
+			delete(lastFetch, g)
+		}
+	}
+
+	// Wake up early for any release coming up before the next check
+	for g, needed := range groups {
+		for _, e := range needed {
+			if release, ok := e.ReleaseAt(g.date); ok {
+				var start time.Time = release.Add(-releaseLead)
+				if start.After(now) && start.Sub(now) < sleep {
+					sleep = hotCheckInterval
+				}
+			}
+		}
+	}
+
+	for _, alert := range alerts {
+		if len(alertGroups[alert.ID]) == 0 {
+			continue
+		}
+		fmt.Println("")
+		fmt.Println("  Checking:", describeAlertTarget(alert), "|", describeAlertDates(alert), "|", alert.StartTime, "–", alert.EndTime)
+
+		// Gather matches across every date and course into one message
+		var matches []MatchedTeeTime
+		var matchedDates []string
+		for _, g := range alertGroups[alert.ID] {
+			var found []MatchedTeeTime = matchAlert(alert, g.date, teeTimesByGroup[g])
+			if alert.NewOnly {
+				// Only slots that opened since the last poll count
+				var opened []MatchedTeeTime
+				for _, m := range found {
+					if newSlots[g][teeTimeKey(m)] {
+						opened = append(opened, m)
+					}
+				}
+				found = opened
+			}
+			if len(found) > 0 {
+				matches = append(matches, found...)
+				matchedDates = append(matchedDates, g.date)
+			}
+		}
+
+		// Continuous alerts only hear about tee times that weren't
+		// open at the previous check
+		var continuous bool = alert.Mode == platforms.AlertModeContinuous
+		var stillSeen []string
+		if continuous {
+			matches, stillSeen = diffSeenTeeTimes(alert.SeenTeeTimes, matches)
+			var saveSeen bool = !sameKeys(stillSeen, alert.SeenTeeTimes)
+			if len(matches) > 0 && alertCoolingDown(alert, now) {
+				fmt.Println("   ", len(matches), "new match(es), cooling down until next window")
+				matches = nil
+			}
+			if len(matches) == 0 && saveSeen {
+				// Forget slots that were booked so a re-release is news
+				_, err = alertStore.Update(alert.ID, func(a *platforms.Alert) error {
+					a.SeenTeeTimes = stillSeen
+					return nil
+				})
+				if err != nil && err != ErrAlertNotFound {
+					fmt.Println("    [ERROR] Saving alert state:", err)
+				}
+			}
+		}
+
+		if len(matches) == 0 {
+			fmt.Println("    No new matches found")
+			continue
+		}
+
+		var msg string = buildAlertMessage(matches, alert.MaxPrice, areaAlert(alert))
+		fmt.Println("   ", len(matches), "match(es) found!")
+
+		// Queue the message and record the alert as notified in one
+		// transaction; the outbox worker handles delivery and retries
+		var channel string = alertChannel(alert)
+		var queued bool
+		queued, err = outbox.Enqueue(OutboxMessage{
+			Key:         notificationKey(alert, matchedDates),
+			AlertID:     alert.ID,
+			Channel:     channel,
+			To:          alertRecipient(alert),
+			Subject:     "Tee time alert: " + describeAlertTarget(alert),
+			Message:     msg,
+			Matches:     matches,
+			MaxPrice:    alert.MaxPrice,
+			Ranked:      areaAlert(alert),
+			NextAttempt: now.Format(time.RFC3339),
+			CreatedAt:   now.Format(time.RFC3339),
+		}, func(a *platforms.Alert) error {
+			// The matches were judged against the alert as loaded at the
+			// start of the pass; if it was edited, paused or notified
+			// since, leave it for the next pass
+			if !reflect.DeepEqual(*a, alert) {
+				return errAlertChanged
+			}
+			if continuous {
+				a.SeenTeeTimes = stillSeen
+				for _, m := range matches {
+					a.SeenTeeTimes = append(a.SeenTeeTimes, teeTimeKey(m))
+				}
+				a.NotificationCount++
+				a.LastNotifiedAt = now.Format(time.RFC3339)
+				if a.NotificationCount >= alertNotificationLimit(*a) {
+					a.Active = false
+				}
+				return nil
+			}
+			for _, date := range matchedDates {
+				markOccurrenceNotified(a, date, today)
+			}
+			return nil
+		})
+		if err == errAlertChanged {
+			fmt.Println("    Alert changed during the check — skipping until the next one")
+			continue
+		}
+		if err != nil {
+			if err != ErrAlertNotFound {
+				fmt.Println("    [ERROR] Queueing notification:", err)
+			}
+			continue
+		}
+		if queued {
+			fmt.Println("    ✓ Queued", channel, "for alert", alert.ID)
+			wakeOutbox()
+		} else {
+			fmt.Println("    Already notified for this occurrence")
+		}
+	}
+
+	fmt.Println("")
+	fmt.Println("  Next check in", sleep, "...")
+	return sleep
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"golf-teetimes/platforms"
+)
+
+func TestBuildAlertMessageUnknownPrice(t *testing.T) {
+	var matches []MatchedTeeTime = []MatchedTeeTime{
+		{Course: "Papago Golf Course", Date: "2026-10-20", Time: "7:10 AM", Openings: 4, Holes: "18", Price: 0},
+		{Course: "Papago Golf Course", Date: "2026-10-20", Time: "7:20 AM", Openings: 2, Holes: "18", Price: 45},
+	}
+	for _, ranked := range []bool{false, true} {
+		var msg string = buildAlertMessage(matches, 0, ranked)
+		if strings.Contains(msg, "$0") {
+			t.Errorf("ranked=%v: unknown price shown as $0:\n%s", ranked, msg)
+		}
+		if !strings.Contains(msg, "7:10 AM (4 openings, 18 holes) - price n/a") || !strings.Contains(msg, "- $45") {
+			t.Errorf("ranked=%v: prices missing:\n%s", ranked, msg)
+		}
+	}
+}
+
+func TestDescribeAlertTargetCourseKey(t *testing.T) {
+	var entry platforms.CourseEntry = platforms.Registry[0]
+	var a platforms.Alert = platforms.Alert{CourseKeys: []string{entry.Key}}
+	if got := describeAlertTarget(a); got != entry.Name || got == "" {
+		t.Errorf("describeAlertTarget = %q, want %q", got, entry.Name)
+	}
+}
+
+func TestTeeTimeExclusionUnknownPrice(t *testing.T) {
+	var alert platforms.Alert = platforms.Alert{StartTime: "7:00 AM", EndTime: "11:00 AM"}
+	var tt platforms.DisplayTeeTime = platforms.DisplayTeeTime{Time: "8:00 AM", Openings: 4, Holes: "18"}
+	var start, end int = parseTimeToMinutes(alert.StartTime), parseTimeToMinutes(alert.EndTime)
+
+	if filter, _ := teeTimeExclusion(alert, tt, start, end); filter != "" {
+		t.Errorf("no price limit: excluded by %s", filter)
+	}
+
+	alert.MaxPrice = 40
+	if filter, reason := teeTimeExclusion(alert, tt, start, end); filter != filterUnpriced || !strings.Contains(reason, "no listed price") {
+		t.Errorf("unknown price under a limit: got %q (%s), want %q", filter, reason, filterUnpriced)
+	}
+	tt.Price = 35
+	if filter, _ := teeTimeExclusion(alert, tt, start, end); filter != "" {
+		t.Errorf("$35 under a $40 limit: excluded by %s", filter)
+	}
+	tt.Price = 45
+	if filter, _ := teeTimeExclusion(alert, tt, start, end); filter != filterPrice {
+		t.Errorf("$45 over a $40 limit: got %q, want %q", filter, filterPrice)
+	}
+}
@@ -1,10 +1,12 @@
-package main
+package app
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
 	"html/template"
 	"net/http"
+	"os"
 	"sort"
 	"strings"
 	"sync"
@@ -12,7 +14,9 @@ import (
 	"golf-teetimes/platforms"
 )
 
-// Rate limiter for alert creation — per IP, max 5 requests per minute
+// Rate limiter for alert creation — per IP, max 5 requests per minute. It is
+// kept in memory, so a restart clears it and each process counts separately;
+// the caps in limits.go are the ones stored with the alerts.
 var alertRateLimit struct {
 	sync.Mutex
 	hits map[string][]time.Time
@@ -23,11 +27,7 @@ func init() {
 }
 
 func alertRateLimited(r *http.Request) bool {
-	ip := r.RemoteAddr
-	if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
-		ip = strings.SplitN(fwd, ",", 2)[0]
-		ip = strings.TrimSpace(ip)
-	}
+	ip := clientIP(r)
 
 	alertRateLimit.Lock()
 	defer alertRateLimit.Unlock()
@@ -132,14 +132,18 @@ type MetroPageData struct {
 	Metro Metro
 }
 
-var (
+var tmplLanding, tmplHome, tmplAlerts, tmplPrivacy, tmplTerms, tmplOptIn *template.Template
+
+// loadTemplates parses the page templates. Only the web server needs them,
+// so alert-worker runs without the templates directory.
+func loadTemplates() {
 	tmplLanding = template.Must(template.ParseFiles("templates/landing.html"))
-	tmplHome    = template.Must(template.ParseFiles("templates/home.html"))
-	tmplAlerts  = template.Must(template.ParseFiles("templates/alerts.html"))
+	tmplHome = template.Must(template.ParseFiles("templates/home.html"))
+	tmplAlerts = template.Must(template.ParseFiles("templates/alerts.html"))
 	tmplPrivacy = template.Must(template.ParseFiles("templates/privacy.html"))
-	tmplTerms   = template.Must(template.ParseFiles("templates/terms.html"))
-	tmplOptIn   = template.Must(template.ParseFiles("templates/optin.html"))
-)
+	tmplTerms = template.Must(template.ParseFiles("templates/terms.html"))
+	tmplOptIn = template.Must(template.ParseFiles("templates/optin.html"))
+}
 
 func handleLanding(w http.ResponseWriter, r *http.Request) {
 	tmplLanding.Execute(w, GetMetroList())
@@ -189,25 +193,66 @@ func handleMetroTeeTimes(w http.ResponseWriter, r *http.Request, metro Metro) {
 }
 
 func fetchMetroTeeTimes(metro Metro, date string) []platforms.DisplayTeeTime {
-	var ch chan fetchResult = make(chan fetchResult)
-	var wg sync.WaitGroup
-
+	var entries []*platforms.CourseEntry
 	for i := range platforms.Registry {
 		entry := &platforms.Registry[i]
 		if entry.Metro != metro.Slug || !entry.Enabled {
 			continue
 		}
+		entries = append(entries, entry)
+	}
+
+	var allResults []platforms.DisplayTeeTime = fetchCourseEntries(entries, date, len(entries))
+
+	// Cache results
+	cacheKey := metro.Slug + ":" + date
+	teeTimeCache.Lock()
+	teeTimeCache.entries[cacheKey] = cachedTeeTimes{data: allResults, fetched: time.Now()}
+	teeTimeCache.Unlock()
+
+	return allResults
+}
+
+// cachedMetroTeeTimes returns the metro's cached tee times, and when they
+// were fetched, if still fresh.
+func cachedMetroTeeTimes(metro string, date string) ([]platforms.DisplayTeeTime, time.Time, bool) {
+	teeTimeCache.RLock()
+	defer teeTimeCache.RUnlock()
+	cached, ok := teeTimeCache.entries[metro+":"+date]
+	if !ok || time.Since(cached.fetched) >= teeTimeCacheTTL {
+		return nil, time.Time{}, false
+	}
+	return cached.data, cached.fetched, true
+}
+
+// fetchCourseEntries fetches the given courses with at most workers requests
+// in flight, caps openings at 4 and sorts by time. It doesn't touch the cache.
+func fetchCourseEntries(entries []*platforms.CourseEntry, date string, workers int) []platforms.DisplayTeeTime {
+	var ch chan fetchResult = make(chan fetchResult)
+	var jobs chan *platforms.CourseEntry = make(chan *platforms.CourseEntry)
+	var wg sync.WaitGroup
+
+	if workers > len(entries) {
+		workers = len(entries)
+	}
+	for w := 0; w < workers; w++ {
 		wg.Add(1)
-		go func(e *platforms.CourseEntry) {
+		go func() {
 			defer wg.Done()
-			var results []platforms.DisplayTeeTime
-			var err error
-			results, err = e.Fetch(date)
-			ch <- fetchResult{results: results, err: err, name: e.Key}
-		}(entry)
+			for e := range jobs {
+				var results []platforms.DisplayTeeTime
+				var err error
+				results, err = e.Fetch(date)
+				ch <- fetchResult{results: results, err: err, name: e.Key}
+			}
+		}()
 	}
 
 	go func() {
+		for _, e := range entries {
+			jobs <- e
+		}
+		close(jobs)
 		wg.Wait()
 		close(ch)
 	}()
@@ -233,12 +278,6 @@ func fetchMetroTeeTimes(metro Metro, date string) []platforms.DisplayTeeTime {
 		return iMins < jMins
 	})
 
-	// Cache results
-	cacheKey := metro.Slug + ":" + date
-	teeTimeCache.Lock()
-	teeTimeCache.entries[cacheKey] = cachedTeeTimes{data: allResults, fetched: time.Now()}
-	teeTimeCache.Unlock()
-
 	return allResults
 }
 
@@ -264,27 +303,20 @@ func handleGetAlerts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var phone string = r.URL.Query().Get("phone")
-	if phone == "" {
+	owner, err := requestOwner(r)
+	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode([]platforms.Alert{})
+		w.WriteHeader(401)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
-	var alerts []platforms.Alert
-	var err error
-	alerts, err = loadAlerts()
+	var filtered []platforms.Alert
+	filtered, err = alertStore.ByPhone(owner)
 	if err != nil {
 		http.Error(w, err.Error(), 500)
 		return
 	}
-
-	var filtered []platforms.Alert
-	for _, a := range alerts {
-		if a.Phone == phone {
-			filtered = append(filtered, a)
-		}
-	}
 	if filtered == nil {
 		filtered = []platforms.Alert{}
 	}
@@ -293,6 +325,14 @@ func handleGetAlerts(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(filtered)
 }
 
+// createAlertRequest is the /api/alerts/create body: the alert plus the
+// state of the consent checkbox. Its Consent field shadows the alert's, so
+// the stored consent record is only ever built by the server.
+type createAlertRequest struct {
+	platforms.Alert
+	Consent bool `json:"consent"`
+}
+
 func handleCreateAlert(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
 		http.Error(w, "Method not allowed", 405)
@@ -306,9 +346,9 @@ func handleCreateAlert(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var incoming platforms.Alert
+	var req createAlertRequest
 	var err error
-	err = json.NewDecoder(r.Body).Decode(&incoming)
+	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(400)
@@ -316,15 +356,28 @@ func handleCreateAlert(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if incoming.Phone == "" || incoming.Course == "" || incoming.Date == "" || incoming.StartTime == "" || incoming.EndTime == "" {
+	var incoming platforms.Alert = req.Alert
+	if (incoming.Phone == "" && incoming.Contact == "") || (incoming.Course == "" && len(incoming.CourseKeys) == 0 && incoming.Metro == "") || (incoming.Date == "" && incoming.Recurrence == nil) || incoming.StartTime == "" || incoming.EndTime == "" {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(400)
 		json.NewEncoder(w).Encode(map[string]string{"error": "All fields are required."})
 		return
 	}
 
+	if alertChannel(incoming) == ChannelSMS && !req.Consent {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(400)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Please agree to receive SMS alerts."})
+		return
+	}
+	incoming.Consent = consentFromRequest(r)
+
+	// Signed-in owners of a verified number skip the code; a missing or
+	// stale token just means a fresh code
+	requester, _ := requestOwner(r)
+
 	var alert platforms.Alert
-	alert, err = addAlert(incoming.Phone, incoming.Course, incoming.Date, incoming.StartTime, incoming.EndTime, incoming.MinPlayers, incoming.Holes)
+	alert, err = addAlert(incoming, requester)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(400)
@@ -332,24 +385,76 @@ func handleCreateAlert(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// First use of a number: the alert waits until the texted code is confirmed
+	if alert.Pending {
+		if err = sendVerificationCode(alert.Phone, alert.ID); err != nil && err != ErrCodeResendTooSoon {
+			fmt.Println("  [ERROR] Sending verification code:", err)
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(alert)
 }
 
+func handleUpdateAlert(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	owner, err := requestOwner(r)
+	if err != nil {
+		w.WriteHeader(401)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	var id string = r.URL.Query().Get("id")
+	var changes platforms.Alert
+	err = json.NewDecoder(r.Body).Decode(&changes)
+	if err != nil || id == "" {
+		w.WriteHeader(400)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body."})
+		return
+	}
+
+	var alert platforms.Alert
+	alert, err = updateAlert(id, owner, changes)
+	if err != nil {
+		w.WriteHeader(400)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	if alert.Pending {
+		if err = sendVerificationCode(alert.Phone, alert.ID); err != nil && err != ErrCodeResendTooSoon {
+			fmt.Println("  [ERROR] Sending verification code:", err)
+		}
+	}
+
+	json.NewEncoder(w).Encode(alert)
+}
+
 func handleDeleteAlert(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
 		http.Error(w, "Method not allowed", 405)
 		return
 	}
 
+	owner, err := requestOwner(r)
+	if err != nil {
+		http.Error(w, err.Error(), 401)
+		return
+	}
+
 	var id string = r.URL.Query().Get("id")
-	var phone string = r.URL.Query().Get("phone")
-	if id == "" || phone == "" {
-		http.Error(w, "ID and phone required", 400)
+	if id == "" {
+		http.Error(w, "ID required", 400)
 		return
 	}
 
-	var err error = deleteAlertByOwner(id, phone)
+	err = deleteAlertByOwner(id, owner)
 	if err != nil {
 		http.Error(w, err.Error(), 400)
 		return
@@ -358,3 +463,68 @@ func handleDeleteAlert(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"status": "deleted"})
 }
+
+// adminAuthorized checks the bearer token against ADMIN_TOKEN. Admin routes
+// are disabled entirely when no token is configured.
+func adminAuthorized(r *http.Request) bool {
+	var token string = os.Getenv("ADMIN_TOKEN")
+	if token == "" {
+		return false
+	}
+	var given string = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
+
+func handleAdminOutbox(w http.ResponseWriter, r *http.Request) {
+	if !adminAuthorized(r) {
+		http.NotFound(w, r)
+		return
+	}
+
+	pending, err := outbox.Pending()
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	dead, err := outbox.Dead()
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	if pending == nil {
+		pending = []OutboxMessage{}
+	}
+	if dead == nil {
+		dead = []OutboxMessage{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]OutboxMessage{"pending": pending, "dead": dead})
+}
+
+func handleAdminOutboxRetry(w http.ResponseWriter, r *http.Request) {
+	if !adminAuthorized(r) {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+
+	var key string = r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, "key required", 400)
+		return
+	}
+
+	var err error = outbox.Requeue(key)
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	wakeOutbox()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "requeued"})
+}
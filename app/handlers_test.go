@@ -0,0 +1,95 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The body static/app.js createAlert sends for an "any course" text alert.
+func homePageAlertBody(date string, consent bool) string {
+	return `{"course":"","metro":"denver","cities":[],"date":"` + date + `","dateTo":"",` +
+		`"startTime":"7:00 AM","endTime":"11:00 AM","minPlayers":2,"holes":"0","maxPrice":0,` +
+		`"recurrence":null,"mode":"once","newOnly":false,` +
+		`"phone":"(303) 555-0100","channel":"sms","contact":"","consent":` + map[bool]string{true: "true", false: "false"}[consent] + `}`
+}
+
+func TestCreateAlertFromHomePage(t *testing.T) {
+	useTestStore(t)
+	var date string = time.Now().AddDate(0, 0, 2).Format("2006-01-02")
+
+	req := httptest.NewRequest("POST", "/api/alerts/create", strings.NewReader(homePageAlertBody(date, true)))
+	req.RemoteAddr = "203.0.113.7:5000"
+	req.Header.Set("User-Agent", "test-browser")
+	rec := httptest.NewRecorder()
+	handleCreateAlert(rec, req)
+
+	if rec.Code != 200 {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		ID      string `json:"id"`
+		Pending bool   `json:"pending"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Pending {
+		t.Error("alert on an unverified number should be pending")
+	}
+
+	stored, err := alertStore.Get(resp.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Consent == nil || stored.Consent.IP != "203.0.113.7" || stored.Consent.UserAgent != "test-browser" || stored.Consent.TextVersion != ConsentTextVersion {
+		t.Errorf("consent = %+v, want the request's address, agent and text version", stored.Consent)
+	}
+}
+
+func TestCreateAlertRequiresSMSConsent(t *testing.T) {
+	useTestStore(t)
+	var date string = time.Now().AddDate(0, 0, 2).Format("2006-01-02")
+
+	req := httptest.NewRequest("POST", "/api/alerts/create", strings.NewReader(homePageAlertBody(date, false)))
+	req.RemoteAddr = "203.0.113.8:5000"
+	rec := httptest.NewRecorder()
+	handleCreateAlert(rec, req)
+
+	if rec.Code != 400 {
+		t.Errorf("status = %d, want 400", rec.Code)
+	}
+}
+
+// A client can't supply its own consent record.
+func TestCreateAlertRejectsConsentRecord(t *testing.T) {
+	useTestStore(t)
+	var date string = time.Now().AddDate(0, 0, 2).Format("2006-01-02")
+	var body string = strings.Replace(homePageAlertBody(date, true), `"consent":true`, `"consent":{"ip":"1.2.3.4"}`, 1)
+
+	req := httptest.NewRequest("POST", "/api/alerts/create", strings.NewReader(body))
+	req.RemoteAddr = "203.0.113.9:5000"
+	rec := httptest.NewRecorder()
+	handleCreateAlert(rec, req)
+
+	if rec.Code != 400 {
+		t.Errorf("status = %d, want 400", rec.Code)
+	}
+}
+
+func TestCreateAlertRejectsUnknownCourse(t *testing.T) {
+	useTestStore(t)
+	var date string = time.Now().AddDate(0, 0, 2).Format("2006-01-02")
+	var body string = strings.Replace(homePageAlertBody(date, true), `"course":""`, `"course":"Nowhere Golf Club"`, 1)
+
+	req := httptest.NewRequest("POST", "/api/alerts/create", strings.NewReader(body))
+	req.RemoteAddr = "203.0.113.10:5000"
+	rec := httptest.NewRecorder()
+	handleCreateAlert(rec, req)
+
+	if rec.Code != 400 || !strings.Contains(rec.Body.String(), "Unknown course") {
+		t.Errorf("status = %d, body %s; want 400 for an unknown course", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Delivery statuses recorded in an alert's history.
+const (
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed"  // will be retried
+	DeliveryDead    = "dead"    // gave up after outboxMaxAttempts
+	DeliveryDropped = "dropped" // not sent: tee times gone, or alert deleted or paused
+)
+
+// NotificationRecord is one delivery attempt of an alert's notification.
+type NotificationRecord struct {
+	AlertID    string           `json:"alertId"`
+	Key        string           `json:"key"` // outbox idempotency key
+	At         string           `json:"at"`
+	Channel    string           `json:"channel"`
+	Status     string           `json:"status"`
+	ProviderID string           `json:"providerId,omitempty"` // e.g. Twilio message SID
+	Error      string           `json:"error,omitempty"`
+	Attempt    int              `json:"attempt"`
+	Matches    []MatchedTeeTime `json:"matches,omitempty"`
+}
+
+// NotificationLog keeps each alert's delivery history. Records outlive their
+// alert, so support can still see what was sent after it expires or is
+// deleted; they are pruned after historyTTL.
+type NotificationLog interface {
+	RecordNotification(rec NotificationRecord) error
+
+	// History returns the alert's records oldest first.
+	History(alertID string) ([]NotificationRecord, error)
+
+	// PruneHistory forgets records older than the cutoff.
+	PruneHistory(before time.Time) (int, error)
+}
+
+var notificationLog NotificationLog
+
+const historyTTL = 90 * 24 * time.Hour
+
+// logDelivery records what happened to an outbox message. Failures are only
+// printed; history must never block delivery.
+func logDelivery(msg OutboxMessage, status string, providerID string, sendErr error, now time.Time) {
+	var rec NotificationRecord = NotificationRecord{
+		AlertID:    msg.AlertID,
+		Key:        msg.Key,
+		At:         now.Format(time.RFC3339),
+		Channel:    msg.Channel,
+		Status:     status,
+		ProviderID: providerID,
+		Attempt:    msg.Attempts + 1,
+		Matches:    msg.Matches,
+	}
+	if sendErr != nil {
+		rec.Error = sendErr.Error()
+	}
+	if err := notificationLog.RecordNotification(rec); err != nil {
+		fmt.Println("  [ERROR] Recording notification history:", err)
+	}
+}
+
+// handleAlertHistory returns an alert's delivery history to its owner, or to
+// support with the admin token.
+func handleAlertHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	var id string = r.URL.Query().Get("id")
+	a, err := alertStore.Get(id)
+	if err != nil && err != ErrAlertNotFound {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	if !adminAuthorized(r) {
+		owner, err := requestOwner(r)
+		if err != nil {
+			w.WriteHeader(401)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		// Someone else's alert looks the same as a missing one
+		if a.ID == "" || alertOwner(a) != owner {
+			w.WriteHeader(404)
+			json.NewEncoder(w).Encode(map[string]string{"error": ErrAlertNotFound.Error()})
+			return
+		}
+	}
+
+	// Support can read the history of an alert that no longer exists
+	history, err := notificationLog.History(id)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	if history == nil {
+		history = []NotificationRecord{}
+	}
+	json.NewEncoder(w).Encode(history)
+}
@@ -0,0 +1,257 @@
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golf-teetimes/platforms"
+)
+
+// Carrier opt-out keywords. Twilio blocks the number itself on these; we also
+// deactivate the number's alerts so the checker stops queueing messages.
+var (
+	smsStopWords  = map[string]bool{"STOP": true, "STOPALL": true, "UNSUBSCRIBE": true, "CANCEL": true, "END": true, "QUIT": true}
+	smsStartWords = map[string]bool{"START": true, "UNSTOP": true, "YES": true}
+)
+
+const smsHelpText = "FreeTeeTimeAlerts: reply LIST to see your alerts, CANCEL <n> to delete one, STOP to pause all alerts, START to resume. Msg & data rates may apply."
+
+// validTwilioSignature checks X-Twilio-Signature: base64 HMAC-SHA1, keyed by
+// the auth token, of the full request URL followed by each POST parameter
+// name and value sorted by name.
+func validTwilioSignature(r *http.Request, authToken string) bool {
+	var signature string = r.Header.Get("X-Twilio-Signature")
+	if signature == "" || authToken == "" {
+		return false
+	}
+
+	var base string = os.Getenv("PUBLIC_URL")
+	if base == "" {
+		base = "https://" + r.Host
+	}
+	var payload strings.Builder
+	payload.WriteString(strings.TrimRight(base, "/") + r.URL.RequestURI())
+
+	var keys []string
+	for k := range r.PostForm {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range r.PostForm[k] {
+			payload.WriteString(k + v)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(payload.String()))
+	var expected string = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// writeTwiML replies to an inbound message. An empty reply sends nothing.
+func writeTwiML(w http.ResponseWriter, reply string) {
+	w.Header().Set("Content-Type", "text/xml")
+	w.Write([]byte(xml.Header + "<Response>"))
+	if reply != "" {
+		w.Write([]byte("<Message>"))
+		xml.EscapeText(w, []byte(reply))
+		w.Write([]byte("</Message>"))
+	}
+	w.Write([]byte("</Response>"))
+}
+
+// handleInboundSMS is Twilio's incoming-message webhook.
+func handleInboundSMS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", 400)
+		return
+	}
+	if !validTwilioSignature(r, os.Getenv("TWILIO_AUTH_TOKEN")) {
+		http.Error(w, "Invalid signature", 403)
+		return
+	}
+
+	var from string = normalizePhone(r.PostForm.Get("From"))
+	var body string = strings.ToUpper(strings.TrimSpace(r.PostForm.Get("Body")))
+	var fields []string = strings.Fields(body)
+	fmt.Println("  Inbound SMS from", from+":", body)
+
+	var reply string
+	var err error
+	switch {
+	case smsStopWords[body]:
+		// Twilio sends the carrier-required confirmation itself
+		err = optOutPhone(from)
+	case smsStartWords[body]:
+		var resumed int
+		resumed, err = optInPhone(from)
+		reply = fmt.Sprintf("FreeTeeTimeAlerts: you're subscribed again. %d alert(s) resumed. Reply HELP for help, STOP to opt out.", resumed)
+	case body == "LIST":
+		reply, err = listAlertsSMS(from)
+	case len(fields) == 2 && (fields[0] == "CANCEL" || fields[0] == "DELETE"):
+		reply, err = cancelAlertSMS(from, fields[1])
+	default:
+		reply = smsHelpText
+	}
+
+	if err != nil {
+		fmt.Println("  [ERROR] Inbound SMS:", err)
+		reply = "Sorry, something went wrong. Please try again later."
+	}
+	// A reply is a text we pay for like any other
+	if reply != "" {
+		var now time.Time = time.Now()
+		recordSent(from, now)
+		if err = usageStore.AddSMSUsage(now, smsSegments(reply)); err != nil {
+			fmt.Println("  [ERROR] Recording SMS usage:", err)
+		}
+	}
+	writeTwiML(w, reply)
+}
+
+// optOutPhone records the opt-out, drops texts still queued or held for the
+// number and deactivates its active alerts, marking them so START can bring
+// back exactly those.
+func optOutPhone(phone string) error {
+	_, err := phoneStore.UpdatePhone(phone, func(p *PhoneRecord) error {
+		p.OptedOut = true
+		p.OptedOutAt = time.Now().Format(time.RFC3339)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	cancelled, err := outbox.CancelTo(ChannelSMS, phone)
+	if err != nil {
+		return err
+	}
+	for _, msg := range cancelled {
+		logDelivery(msg, DeliveryDropped, "", errors.New("number opted out"), time.Now())
+	}
+
+	alerts, err := alertStore.ByPhone(phone)
+	if err != nil {
+		return err
+	}
+	for _, a := range alerts {
+		if !a.Active {
+			continue
+		}
+		_, err = alertStore.Update(a.ID, func(a *platforms.Alert) error {
+			a.Active = false
+			a.OptedOut = true
+			return nil
+		})
+		if err != nil && err != ErrAlertNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+// optInPhone clears the opt-out and reactivates alerts paused by STOP.
+func optInPhone(phone string) (int, error) {
+	_, err := phoneStore.UpdatePhone(phone, func(p *PhoneRecord) error {
+		p.OptedOut = false
+		p.OptedOutAt = ""
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	alerts, err := alertStore.ByPhone(phone)
+	if err != nil {
+		return 0, err
+	}
+	var resumed int
+	for _, a := range alerts {
+		if !a.OptedOut {
+			continue
+		}
+		_, err = alertStore.Update(a.ID, func(a *platforms.Alert) error {
+			a.Active = true
+			a.OptedOut = false
+			return nil
+		})
+		if err != nil && err != ErrAlertNotFound {
+			return resumed, err
+		}
+		resumed++
+	}
+	return resumed, nil
+}
+
+// activeAlertsForSMS lists the number's active SMS alerts oldest first, the
+// numbering LIST shows and CANCEL <n> refers to.
+func activeAlertsForSMS(phone string) ([]platforms.Alert, error) {
+	alerts, err := alertStore.ByPhone(phone)
+	if err != nil {
+		return nil, err
+	}
+	var active []platforms.Alert
+	for _, a := range alerts {
+		if a.Active && alertChannel(a) == ChannelSMS {
+			active = append(active, a)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].ID < active[j].ID
+	})
+	return active, nil
+}
+
+func listAlertsSMS(phone string) (string, error) {
+	alerts, err := activeAlertsForSMS(phone)
+	if err != nil {
+		return "", err
+	}
+	if len(alerts) == 0 {
+		return "You have no active tee time alerts.", nil
+	}
+
+	var msg string = "Your alerts:\n"
+	for i, a := range alerts {
+		msg += fmt.Sprintf("%d. %s, %s, %s–%s\n", i+1, describeAlertTarget(a), describeAlertDates(a), a.StartTime, a.EndTime)
+	}
+	msg += "Reply CANCEL <n> to delete one."
+	return msg, nil
+}
+
+func cancelAlertSMS(phone string, arg string) (string, error) {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 {
+		return "Reply LIST to see your alerts, then CANCEL <n> with the alert's number.", nil
+	}
+
+	alerts, err := activeAlertsForSMS(phone)
+	if err != nil {
+		return "", err
+	}
+	if n > len(alerts) {
+		return fmt.Sprintf("You have %d active alert(s). Reply LIST to see them.", len(alerts)), nil
+	}
+
+	var a platforms.Alert = alerts[n-1]
+	err = deleteAlertByOwner(a.ID, phone)
+	if err != nil && err != ErrAlertNotFound {
+		return "", err
+	}
+	return "Deleted your alert for " + describeAlertTarget(a) + " on " + describeAlertDates(a) + ".", nil
+}
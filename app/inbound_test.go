@@ -0,0 +1,59 @@
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// postInboundSMS sends a signed Twilio webhook for a text from phone.
+func postInboundSMS(t *testing.T, phone string, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	t.Setenv("TWILIO_AUTH_TOKEN", "test-token")
+	t.Setenv("PUBLIC_URL", "https://example.com")
+
+	var form url.Values = url.Values{"From": {phone}, "Body": {body}}
+	var payload string = "https://example.com/api/sms/inbound" + "Body" + body + "From" + phone
+	mac := hmac.New(sha1.New, []byte("test-token"))
+	mac.Write([]byte(payload))
+
+	req := httptest.NewRequest("POST", "/api/sms/inbound", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Twilio-Signature", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	rec := httptest.NewRecorder()
+	handleInboundSMS(rec, req)
+	if rec.Code != 200 {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	return rec
+}
+
+func TestInboundReplyCountsAsSent(t *testing.T) {
+	useTestStore(t)
+	var phone string = "+13035550100"
+
+	var rec *httptest.ResponseRecorder = postInboundSMS(t, phone, "HELP")
+	if !strings.Contains(rec.Body.String(), "<Message>") {
+		t.Fatalf("no reply: %s", rec.Body.String())
+	}
+
+	day, _, err := usageStore.SMSUsage(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if day.Messages != 1 || day.Segments != smsSegments(smsHelpText) {
+		t.Errorf("usage = %+v, want 1 message of %d segments", day, smsSegments(smsHelpText))
+	}
+	p, err := phoneStore.GetPhone(phone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.SentCount != 1 {
+		t.Errorf("SentCount = %d, want 1", p.SentCount)
+	}
+}
@@ -0,0 +1,259 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golf-teetimes/platforms"
+)
+
+// Abuse and cost limits. Each can be overridden by the environment variable
+// named alongside it; 0 turns a limit off.
+const (
+	defaultMaxAlertsPerOwner = 10     // MAX_ALERTS_PER_OWNER: active or pending alerts per phone or contact
+	defaultMaxActiveAlerts   = 5000   // MAX_ACTIVE_ALERTS: across everyone
+	defaultMaxSMSPerPhoneDay = 20     // MAX_SMS_PER_PHONE_DAY
+	defaultMaxSMSPerDay      = 2000   // MAX_SMS_PER_DAY: across everyone
+	defaultSMSSegmentCost    = 0.0083 // SMS_SEGMENT_COST: USD per 160-character segment
+	// SMS_MONTHLY_BUDGET: USD; unset means no budget
+)
+
+var ErrSMSSuspended = errors.New("Text messages are paused right now. Please try again later.")
+
+var ErrSMSPhoneCapped = errors.New("This number has reached its text limit for today. Please try again tomorrow.")
+
+func envInt(key string, fallback int) int {
+	n, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return n
+}
+
+func envFloat(key string, fallback float64) float64 {
+	f, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return fallback
+	}
+	return f
+}
+
+// SMSUsage counts the text messages sent in one day or month. Segments are
+// what Twilio bills.
+type SMSUsage struct {
+	Messages int `json:"messages"`
+	Segments int `json:"segments"`
+}
+
+// UsageStore keeps SMS counters per UTC day and month, so caps and the budget
+// hold across restarts.
+type UsageStore interface {
+	AddSMSUsage(now time.Time, segments int) error
+	SMSUsage(now time.Time) (day SMSUsage, month SMSUsage, err error)
+}
+
+var usageStore UsageStore
+
+// smsSegments estimates how many segments Twilio splits a message into.
+func smsSegments(message string) int {
+	var n int = len([]rune(message))
+	if n <= 160 {
+		return 1
+	}
+	return (n + 152) / 153
+}
+
+// smsBlockedUntil returns when texting may resume if the global daily cap or
+// the monthly budget is used up, with the reason; otherwise the zero time.
+func smsBlockedUntil(now time.Time) (time.Time, string, error) {
+	day, month, err := usageStore.SMSUsage(now)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	var utc time.Time = now.UTC()
+	if limit := envInt("MAX_SMS_PER_DAY", defaultMaxSMSPerDay); limit > 0 && day.Messages >= limit {
+		return time.Date(utc.Year(), utc.Month(), utc.Day()+1, 0, 0, 0, 0, time.UTC), "daily SMS limit reached", nil
+	}
+	if budget := envFloat("SMS_MONTHLY_BUDGET", 0); budget > 0 && smsSpend(month) >= budget {
+		return time.Date(utc.Year(), utc.Month()+1, 1, 0, 0, 0, 0, time.UTC), "monthly SMS budget spent", nil
+	}
+	return time.Time{}, "", nil
+}
+
+func smsSpend(u SMSUsage) float64 {
+	return float64(u.Segments) * envFloat("SMS_SEGMENT_COST", defaultSMSSegmentCost)
+}
+
+// smsPhoneDailyLimit is the number's own cap or the server's, whichever is
+// lower.
+func smsPhoneDailyLimit(p NotificationPrefs) int {
+	var limit int = envInt("MAX_SMS_PER_PHONE_DAY", defaultMaxSMSPerPhoneDay)
+	if p.MaxPerDay > 0 && (limit <= 0 || p.MaxPerDay < limit) {
+		limit = p.MaxPerDay
+	}
+	return limit
+}
+
+// liveAlert reports whether an alert counts toward the caps.
+func liveAlert(a platforms.Alert) bool {
+	return a.Active || a.Pending
+}
+
+// checkOwnerAlertLimit refuses an alert that would give its owner more live
+// alerts than allowed. existing are the owner's alerts.
+func checkOwnerAlertLimit(alert platforms.Alert, existing []platforms.Alert) error {
+	var limit int = envInt("MAX_ALERTS_PER_OWNER", defaultMaxAlertsPerOwner)
+	if limit <= 0 {
+		return nil
+	}
+	var live int
+	for _, e := range existing {
+		if e.ID != alert.ID && liveAlert(e) {
+			live++
+		}
+	}
+	if live >= limit {
+		return fmt.Errorf("You can have at most %d active alerts. Delete one to add another.", limit)
+	}
+	return nil
+}
+
+// checkActiveAlertLimit refuses to make alert id live once the service
+// watches as many alerts as it's configured for.
+func checkActiveAlertLimit(id string) error {
+	var limit int = envInt("MAX_ACTIVE_ALERTS", defaultMaxActiveAlerts)
+	if limit <= 0 {
+		return nil
+	}
+	alerts, err := alertStore.List()
+	if err != nil {
+		return err
+	}
+	var live int
+	for _, a := range alerts {
+		if a.ID != id && liveAlert(a) {
+			live++
+		}
+	}
+	if live >= limit {
+		return errors.New("We're at capacity for new alerts right now. Please try again later.")
+	}
+	return nil
+}
+
+// trustedProxies are the TRUSTED_PROXIES addresses or CIDR ranges allowed
+// to report a client's address in X-Forwarded-For, comma separated. Set it
+// to the load balancer's range when running behind one; unset, every client
+// shares the proxy's address and so its rate limits. The per-IP limit itself
+// (alertRateLimited) is only held in memory and starts over on restart.
+var trustedProxies struct {
+	once     sync.Once
+	prefixes []netip.Prefix
+}
+
+// warnTrustedProxies notes at startup when X-Forwarded-For will be ignored.
+func warnTrustedProxies() {
+	if strings.TrimSpace(os.Getenv("TRUSTED_PROXIES")) == "" {
+		fmt.Println("[WARN] TRUSTED_PROXIES is not set, so X-Forwarded-For is ignored. Behind a proxy, every client shares the proxy's rate limits.")
+	}
+}
+
+func isTrustedProxy(ip string) bool {
+	trustedProxies.once.Do(func() {
+		for _, s := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+			s = strings.TrimSpace(s)
+			if s == "" {
+				continue
+			}
+			if !strings.Contains(s, "/") {
+				if addr, err := netip.ParseAddr(s); err == nil {
+					s = netip.PrefixFrom(addr, addr.BitLen()).String()
+				}
+			}
+			prefix, err := netip.ParsePrefix(s)
+			if err != nil {
+				fmt.Println("  [WARN] Ignoring TRUSTED_PROXIES entry:", s)
+				continue
+			}
+			trustedProxies.prefixes = append(trustedProxies.prefixes, prefix.Masked())
+		}
+	})
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range trustedProxies.prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the caller's address. X-Forwarded-For is only believed
+// when the connection comes from a trusted proxy, and then read from the
+// right, skipping further trusted hops, since clients can prepend anything.
+func clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	if !isTrustedProxy(ip) {
+		return ip
+	}
+	var hops []string = strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		var hop string = strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		if !isTrustedProxy(hop) {
+			return hop
+		}
+		ip = hop
+	}
+	return ip
+}
+
+func handleAdminUsage(w http.ResponseWriter, r *http.Request) {
+	if !adminAuthorized(r) {
+		http.NotFound(w, r)
+		return
+	}
+
+	var now time.Time = time.Now()
+	day, month, err := usageStore.SMSUsage(now)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	until, reason, err := smsBlockedUntil(now)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	var resp map[string]interface{} = map[string]interface{}{
+		"today":         day,
+		"month":         month,
+		"monthSpend":    smsSpend(month),
+		"monthlyBudget": envFloat("SMS_MONTHLY_BUDGET", 0),
+		"dailyLimit":    envInt("MAX_SMS_PER_DAY", defaultMaxSMSPerDay),
+	}
+	if !until.IsZero() {
+		resp["suspendedUntil"] = until.Format(time.RFC3339)
+		resp["suspendedReason"] = reason
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
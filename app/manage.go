@@ -0,0 +1,181 @@
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Management tokens prove control of an alert owner (phone number or contact
+// address). They're stateless: base64url(owner).expiryUnix.base64url(HMAC).
+const (
+	manageLinkTTL    = 24 * time.Hour
+	manageSessionTTL = 30 * 24 * time.Hour
+)
+
+var ErrInvalidToken = errors.New("Your sign-in link is invalid or has expired. Request a new one.")
+
+// manageKey signs management tokens. It comes from ALERTS_SECRET, or a random
+// key kept in the database so tokens survive restarts.
+var manageKey []byte
+
+func loadManageKey(store *boltAlertStore) error {
+	if secret := os.Getenv("ALERTS_SECRET"); secret != "" {
+		manageKey = []byte(secret)
+		return nil
+	}
+	var err error
+	manageKey, err = store.Secret("manage")
+	return err
+}
+
+func signManageToken(owner string, ttl time.Duration) string {
+	var payload string = base64.RawURLEncoding.EncodeToString([]byte(owner)) + "." + strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	mac := hmac.New(sha256.New, manageKey)
+	mac.Write([]byte(payload))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verifyManageToken returns the owner a valid, unexpired token was issued to.
+func verifyManageToken(token string) (string, error) {
+	var parts []string = strings.Split(token, ".")
+	if len(parts) != 3 || len(manageKey) == 0 {
+		return "", ErrInvalidToken
+	}
+
+	mac := hmac.New(sha256.New, manageKey)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	given, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(given, mac.Sum(nil)) {
+		return "", ErrInvalidToken
+	}
+
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return "", ErrInvalidToken
+	}
+
+	owner, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	return string(owner), nil
+}
+
+// requestOwner authenticates the management token sent as a bearer token or
+// ?token= parameter.
+func requestOwner(r *http.Request) (string, error) {
+	var token string = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	return verifyManageToken(token)
+}
+
+// sendManageLink delivers a sign-in link to the owner itself: by text to a
+// phone number that has been verified, or over the channel of the owner's
+// most recent alert when the owner is that alert's contact. The link never
+// goes anywhere but the owner, since an alert's contact can be set by
+// whoever created it. Owners without alerts get nothing.
+func sendManageLink(owner string, metro string) error {
+	alerts, err := alertStore.ByPhone(owner)
+	if err != nil || len(alerts) == 0 {
+		return err
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].ID > alerts[j].ID
+	})
+	var latest = alerts[0]
+
+	var channel string
+	if latest.Phone == owner {
+		rec, err := phoneStore.GetPhone(owner)
+		if err != nil {
+			return err
+		}
+		if rec.VerifiedAt == "" || rec.OptedOut {
+			return nil
+		}
+		channel = ChannelSMS
+	} else {
+		for _, a := range alerts {
+			if a.Phone == "" && a.Contact == owner {
+				latest = a
+				break
+			}
+		}
+		if latest.Phone != "" || latest.Contact != owner {
+			return nil
+		}
+		channel = alertChannel(latest)
+	}
+
+	var base string = os.Getenv("PUBLIC_URL")
+	if base == "" {
+		base = "https://freeteetimealerts.com"
+	}
+	if _, ok := Metros[metro]; !ok {
+		metro = ""
+		if metros := alertMetros(latest); len(metros) > 0 {
+			metro = metros[0]
+		}
+	}
+	var link string = strings.TrimRight(base, "/") + "/" + metro + "/alerts#token=" + signManageToken(owner, manageLinkTTL)
+	var message string = "Manage your tee time alerts: " + link + "\nThis link expires in 24 hours."
+
+	// Texts go through sendSMS so they count toward the number's cap
+	if channel == ChannelSMS {
+		return sendSMS(owner, message)
+	}
+	notifier, err := notifierFor(channel)
+	if err != nil {
+		return err
+	}
+	_, err = notifier.Send(Notification{
+		AlertID: latest.ID,
+		To:      owner,
+		Subject: "Manage your tee time alerts",
+		Message: message,
+	})
+	return err
+}
+
+func handleManageLink(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	if alertRateLimited(r) {
+		w.WriteHeader(429)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Too many requests. Please try again in a minute."})
+		return
+	}
+
+	var req struct {
+		Contact string `json:"contact"`
+		Metro   string `json:"metro"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Contact == "" {
+		w.WriteHeader(400)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Phone number or email required."})
+		return
+	}
+
+	// Same response whether or not the owner exists, so this can't be used
+	// to probe who has alerts
+	if err := sendManageLink(normalizePhone(req.Contact), req.Metro); err != nil {
+		fmt.Println("  [ERROR] Sending manage link:", err)
+	}
+	json.NewEncoder(w).Encode(map[string]string{"status": "sent"})
+}
@@ -0,0 +1,385 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"golf-teetimes/platforms"
+)
+
+// Delivery channels an alert can pick. SMS is the default.
+const (
+	ChannelSMS     = "sms"
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+	ChannelPush    = "push"
+)
+
+// Notification is one message to deliver for an alert.
+type Notification struct {
+	AlertID        string           `json:"alertId"`
+	IdempotencyKey string           `json:"idempotencyKey,omitempty"`
+	To             string           `json:"-"`
+	Subject        string           `json:"subject"`
+	Message        string           `json:"message"`
+	Matches        []MatchedTeeTime `json:"matches,omitempty"`
+}
+
+// Notifier delivers a notification over one channel and returns the
+// provider's message ID when it has one.
+type Notifier interface {
+	Send(n Notification) (string, error)
+}
+
+var notifyClient = &http.Client{Timeout: 15 * time.Second}
+
+// webhookClient only connects to public addresses, checked at dial time so
+// redirects and DNS changes after validation can't reach internal services.
+// It ignores proxy settings for the same reason.
+var webhookClient = &http.Client{
+	Timeout: 15 * time.Second,
+	Transport: &http.Transport{
+		Proxy: nil,
+		DialContext: (&net.Dialer{
+			Timeout: 10 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+					return fmt.Errorf("webhook address %s is not public", host)
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+	},
+}
+
+// publicIP reports whether ip is routable on the internet: not loopback,
+// private, link-local (which includes cloud metadata at 169.254.169.254),
+// multicast or unspecified.
+func publicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
+
+// validateWebhookHost resolves host and refuses it if any address isn't
+// public.
+func validateWebhookHost(host string) error {
+	ips, err := net.LookupIP(host)
+	if err != nil || len(ips) == 0 {
+		return errors.New("We couldn't find that webhook's host.")
+	}
+	for _, ip := range ips {
+		if !publicIP(ip) {
+			return errors.New("Webhook URLs must point to a public address.")
+		}
+	}
+	return nil
+}
+
+// notifierFor builds the notifier for a channel from the environment. Each
+// provider's base URL can be overridden to point at a local stand-in server.
+func notifierFor(channel string) (Notifier, error) {
+	switch channel {
+	case "", ChannelSMS:
+		return twilioNotifier{
+			BaseURL:    envOr("TWILIO_API_URL", "https://api.twilio.com"),
+			AccountSID: os.Getenv("TWILIO_ACCOUNT_SID"),
+			AuthToken:  os.Getenv("TWILIO_AUTH_TOKEN"),
+			From:       os.Getenv("TWILIO_FROM_NUMBER"),
+		}, nil
+	case ChannelEmail:
+		return smtpNotifier{
+			Addr:     net.JoinHostPort(os.Getenv("SMTP_HOST"), envOr("SMTP_PORT", "587")),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("SMTP_FROM"),
+		}, nil
+	case ChannelWebhook:
+		return webhookNotifier{}, nil
+	case ChannelPush:
+		return ntfyNotifier{Server: envOr("NTFY_SERVER", "https://ntfy.sh")}, nil
+	}
+	return nil, errors.New("unknown notification channel: " + channel)
+}
+
+func envOr(key string, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// alertChannel returns the alert's delivery channel, defaulting to SMS.
+func alertChannel(a platforms.Alert) string {
+	if a.Channel == "" {
+		return ChannelSMS
+	}
+	return a.Channel
+}
+
+// alertRecipient is where an alert's notifications go: the phone for SMS,
+// otherwise the Contact address, URL or topic.
+func alertRecipient(a platforms.Alert) string {
+	if alertChannel(a) == ChannelSMS {
+		return a.Phone
+	}
+	return a.Contact
+}
+
+// alertOwner identifies who an alert belongs to for lookups and deletes.
+// Alerts without a phone are owned by their contact address.
+func alertOwner(a platforms.Alert) string {
+	if a.Phone != "" {
+		return a.Phone
+	}
+	return a.Contact
+}
+
+var ntfyTopicRe = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// validateChannel checks that the alert has a destination its channel can
+// deliver to. Email contacts are reduced to the bare address.
+func validateChannel(a *platforms.Alert) error {
+	switch alertChannel(*a) {
+	case ChannelSMS:
+		if a.Phone == "" {
+			return errors.New("A phone number is required for text alerts.")
+		}
+	case ChannelEmail:
+		addr, err := mail.ParseAddress(a.Contact)
+		if err != nil {
+			return errors.New("Enter a valid email address.")
+		}
+		a.Contact = addr.Address
+	case ChannelWebhook:
+		u, err := url.Parse(a.Contact)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Hostname() == "" {
+			return errors.New("Enter a valid webhook URL.")
+		}
+		if err := validateWebhookHost(u.Hostname()); err != nil {
+			return err
+		}
+	case ChannelPush:
+		if !ntfyTopicRe.MatchString(a.Contact) {
+			return errors.New("Push topics may only use letters, numbers, - and _.")
+		}
+	default:
+		return errors.New("Unknown notification channel: " + a.Channel)
+	}
+	return nil
+}
+
+// twilioNotifier sends SMS through Twilio's Messages API.
+type twilioNotifier struct {
+	BaseURL    string
+	AccountSID string
+	AuthToken  string
+	From       string
+}
+
+func (t twilioNotifier) Send(n Notification) (string, error) {
+	if t.AccountSID == "" || t.AuthToken == "" || t.From == "" {
+		return "", fmt.Errorf("TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN, or TWILIO_FROM_NUMBER not set")
+	}
+
+	// Nothing goes out past the daily cap or monthly budget
+	var now time.Time = time.Now()
+	until, _, err := smsBlockedUntil(now)
+	if err != nil {
+		return "", err
+	}
+	if !until.IsZero() {
+		return "", ErrSMSSuspended
+	}
+
+	var body string = n.Message + "\nReply STOP to unsubscribe"
+	data := url.Values{}
+	data.Set("From", t.From)
+	data.Set("To", n.To)
+	data.Set("Body", body)
+	apiURL := strings.TrimRight(t.BaseURL, "/") + "/2010-04-01/Accounts/" + t.AccountSID + "/Messages.json"
+
+	req, err := http.NewRequest("POST", apiURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(t.AccountSID, t.AuthToken)
+
+	resp, err := notifyClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("twilio API error %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result struct {
+		SID         string `json:"sid"`
+		NumSegments string `json:"num_segments"`
+	}
+	json.Unmarshal(respBody, &result)
+
+	segments, err := strconv.Atoi(result.NumSegments)
+	if err != nil || segments < 1 {
+		segments = smsSegments(body)
+	}
+	if err = usageStore.AddSMSUsage(now, segments); err != nil {
+		fmt.Println("  [ERROR] Recording SMS usage:", err)
+	}
+	return result.SID, nil
+}
+
+// smtpNotifier sends plain-text email. Username may be empty for relays that
+// don't require auth.
+type smtpNotifier struct {
+	Addr     string
+	Username string
+	Password string
+	From     string
+}
+
+func (s smtpNotifier) Send(n Notification) (string, error) {
+	host, _, err := net.SplitHostPort(s.Addr)
+	if err != nil || host == "" || s.From == "" {
+		return "", fmt.Errorf("SMTP_HOST or SMTP_FROM not set")
+	}
+	// Alerts saved before contacts were normalized may hold "Name <addr>"
+	to, err := mail.ParseAddress(n.To)
+	if err != nil {
+		return "", fmt.Errorf("invalid email address: %w", err)
+	}
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+
+	// Retries of the same notification reuse its Message-ID so mail clients
+	// can collapse duplicates
+	var messageID string = fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), n.AlertID, host)
+	if n.IdempotencyKey != "" {
+		messageID = "<" + strings.NewReplacer(":", ".", ",", ".").Replace(n.IdempotencyKey) + "@" + host + ">"
+	}
+	var msg bytes.Buffer
+	msg.WriteString("From: " + s.From + "\r\n")
+	msg.WriteString("To: " + to.Address + "\r\n")
+	msg.WriteString("Subject: " + n.Subject + "\r\n")
+	msg.WriteString("Message-ID: " + messageID + "\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(n.Message, "\n", "\r\n"))
+
+	err = smtp.SendMail(s.Addr, auth, s.From, []string{to.Address}, msg.Bytes())
+	if err != nil {
+		return "", err
+	}
+	return messageID, nil
+}
+
+// webhookNotifier POSTs the notification as JSON to the alert's URL.
+type webhookNotifier struct{}
+
+func (webhookNotifier) Send(n Notification) (string, error) {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest("POST", n.To, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.IdempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", n.IdempotencyKey)
+	}
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("webhook error %d: %s", resp.StatusCode, string(respBody))
+	}
+	return "", nil
+}
+
+// ntfyNotifier publishes to an ntfy-style topic: the message is the request
+// body and the title and click-through link go in headers.
+type ntfyNotifier struct {
+	Server string
+}
+
+func (p ntfyNotifier) Send(n Notification) (string, error) {
+	req, err := http.NewRequest("POST", strings.TrimRight(p.Server, "/")+"/"+n.To, strings.NewReader(n.Message))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Title", n.Subject)
+	req.Header.Set("Tags", "golf")
+	if len(n.Matches) > 0 {
+		req.Header.Set("Click", bookingURLForCourse(n.Matches[0].Course))
+	}
+
+	resp, err := notifyClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("push error %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		ID string `json:"id"`
+	}
+	json.Unmarshal(body, &result)
+	return result.ID, nil
+}
+
+// sendSMS delivers a one-off text outside of an alert notification. It
+// counts toward the number's daily cap like alert texts do, and is refused
+// once the cap is reached.
+func sendSMS(to string, message string) error {
+	var now time.Time = time.Now()
+	rec, err := phoneStore.GetPhone(to)
+	if err != nil {
+		return err
+	}
+	if phoneCapReached(rec, now) {
+		return ErrSMSPhoneCapped
+	}
+
+	notifier, _ := notifierFor(ChannelSMS)
+	if _, err = notifier.Send(Notification{To: to, Message: message}); err != nil {
+		return err
+	}
+	recordSent(to, now)
+	return nil
+}
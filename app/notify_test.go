@@ -0,0 +1,311 @@
+package app
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTwilioNotifierSend(t *testing.T) {
+	useTestStore(t)
+	var got *http.Request
+	var form map[string][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		got, form = r, r.PostForm
+		w.Write([]byte(`{"sid":"SM123","num_segments":"2"}`))
+	}))
+	defer srv.Close()
+
+	var notifier twilioNotifier = twilioNotifier{BaseURL: srv.URL, AccountSID: "AC1", AuthToken: "secret", From: "+13035550000"}
+	sid, err := notifier.Send(Notification{To: "+13035550100", Message: "Tee times open"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sid != "SM123" {
+		t.Errorf("sid = %q, want SM123", sid)
+	}
+	if got.Method != "POST" || got.URL.Path != "/2010-04-01/Accounts/AC1/Messages.json" {
+		t.Errorf("request = %s %s", got.Method, got.URL.Path)
+	}
+	if user, pass, ok := got.BasicAuth(); !ok || user != "AC1" || pass != "secret" {
+		t.Errorf("basic auth = %q, %q, %v", user, pass, ok)
+	}
+	if form["From"][0] != "+13035550000" || form["To"][0] != "+13035550100" {
+		t.Errorf("form = %v", form)
+	}
+	if !strings.HasPrefix(form["Body"][0], "Tee times open\n") || !strings.Contains(form["Body"][0], "STOP") {
+		t.Errorf("body = %q", form["Body"][0])
+	}
+
+	day, _, err := usageStore.SMSUsage(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if day.Messages != 1 || day.Segments != 2 {
+		t.Errorf("usage = %+v, want 1 message, 2 segments", day)
+	}
+}
+
+func TestTwilioNotifierError(t *testing.T) {
+	useTestStore(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(400)
+		w.Write([]byte(`{"code":21211,"message":"invalid To"}`))
+	}))
+	defer srv.Close()
+
+	var notifier twilioNotifier = twilioNotifier{BaseURL: srv.URL, AccountSID: "AC1", AuthToken: "secret", From: "+13035550000"}
+	_, err := notifier.Send(Notification{To: "+1", Message: "hi"})
+	if err == nil || !strings.Contains(err.Error(), "twilio API error 400") || !strings.Contains(err.Error(), "invalid To") {
+		t.Errorf("err = %v", err)
+	}
+	day, _, _ := usageStore.SMSUsage(time.Now())
+	if day.Messages != 0 {
+		t.Errorf("failed send counted toward usage: %+v", day)
+	}
+
+	if _, err = (twilioNotifier{BaseURL: srv.URL}).Send(Notification{To: "+1", Message: "hi"}); err == nil {
+		t.Error("sent without credentials")
+	}
+}
+
+func TestNtfyNotifierSend(t *testing.T) {
+	var got *http.Request
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		got, body = r, string(raw)
+		w.Write([]byte(`{"id":"msg1"}`))
+	}))
+	defer srv.Close()
+
+	id, err := ntfyNotifier{Server: srv.URL + "/"}.Send(Notification{To: "my-topic", Subject: "Tee times", Message: "7:10 AM"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "msg1" {
+		t.Errorf("id = %q, want msg1", id)
+	}
+	if got.URL.Path != "/my-topic" || body != "7:10 AM" {
+		t.Errorf("request = %s %q", got.URL.Path, body)
+	}
+	if got.Header.Get("Title") != "Tee times" {
+		t.Errorf("Title = %q", got.Header.Get("Title"))
+	}
+}
+
+func TestNtfyNotifierError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", 429)
+	}))
+	defer srv.Close()
+
+	_, err := ntfyNotifier{Server: srv.URL}.Send(Notification{To: "my-topic", Message: "hi"})
+	if err == nil || !strings.Contains(err.Error(), "push error 429") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+// useWebhookClient lets webhooks reach the loopback test server.
+func useWebhookClient(t *testing.T, client *http.Client) {
+	var saved *http.Client = webhookClient
+	webhookClient = client
+	t.Cleanup(func() { webhookClient = saved })
+}
+
+func TestWebhookNotifierSend(t *testing.T) {
+	var got *http.Request
+	var payload Notification
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		json.NewDecoder(r.Body).Decode(&payload)
+	}))
+	defer srv.Close()
+	useWebhookClient(t, srv.Client())
+
+	_, err := webhookNotifier{}.Send(Notification{AlertID: "a1", IdempotencyKey: "a1:2026-10-20", To: srv.URL + "/hook", Subject: "Tee times", Message: "7:10 AM"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Method != "POST" || got.URL.Path != "/hook" || got.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("request = %s %s %s", got.Method, got.URL.Path, got.Header.Get("Content-Type"))
+	}
+	if got.Header.Get("Idempotency-Key") != "a1:2026-10-20" {
+		t.Errorf("Idempotency-Key = %q", got.Header.Get("Idempotency-Key"))
+	}
+	if payload.AlertID != "a1" || payload.Message != "7:10 AM" {
+		t.Errorf("payload = %+v", payload)
+	}
+}
+
+func TestWebhookNotifierError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", 500)
+	}))
+	defer srv.Close()
+	useWebhookClient(t, srv.Client())
+
+	_, err := webhookNotifier{}.Send(Notification{To: srv.URL})
+	if err == nil || !strings.Contains(err.Error(), "webhook error 500") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+// The real client refuses to connect to the loopback test server.
+func TestWebhookClientRejectsPrivateAddresses(t *testing.T) {
+	var called bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	_, err := webhookNotifier{}.Send(Notification{To: srv.URL})
+	if err == nil || !strings.Contains(err.Error(), "is not public") {
+		t.Errorf("err = %v", err)
+	}
+	if called {
+		t.Error("webhook reached a loopback address")
+	}
+
+	for _, addr := range []string{"127.0.0.1", "10.1.2.3", "192.168.0.1", "169.254.169.254", "::1", "fe80::1", "0.0.0.0"} {
+		if publicIP(net.ParseIP(addr)) {
+			t.Errorf("%s counted as public", addr)
+		}
+	}
+	if !publicIP(net.ParseIP("8.8.8.8")) {
+		t.Error("8.8.8.8 not counted as public")
+	}
+}
+
+// fakeSMTP is a minimal SMTP server that records one message. RCPT fails for
+// addresses in reject.
+type fakeSMTP struct {
+	addr   string
+	auth   string
+	from   string
+	to     []string
+	data   string
+	reject string
+	done   chan struct{}
+}
+
+func startFakeSMTP(t *testing.T, reject string) *fakeSMTP {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	var s *fakeSMTP = &fakeSMTP{addr: ln.Addr().String(), reject: reject, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s.serve(conn)
+	}()
+	return s
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	var r *bufio.Reader = bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		var verb string = strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch verb {
+		case "EHLO", "HELO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			var fields []string = strings.Fields(line)
+			raw, _ := base64.StdEncoding.DecodeString(fields[len(fields)-1])
+			s.auth = string(raw)
+			reply("235 OK")
+		case "MAIL":
+			s.from = line
+			reply("250 OK")
+		case "RCPT":
+			if s.reject != "" && strings.Contains(line, s.reject) {
+				reply("550 No such user")
+				continue
+			}
+			s.to = append(s.to, line)
+			reply("250 OK")
+		case "DATA":
+			reply("354 Go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil || l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.data = data.String()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestSMTPNotifierSend(t *testing.T) {
+	var srv *fakeSMTP = startFakeSMTP(t, "")
+	var notifier smtpNotifier = smtpNotifier{Addr: srv.addr, Username: "user", Password: "pass", From: "alerts@example.com"}
+
+	id, err := notifier.Send(Notification{AlertID: "a1", IdempotencyKey: "a1:2026-10-20", To: "Golfer <golfer@example.com>", Subject: "Tee times", Message: "7:10 AM\n8:00 AM"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-srv.done
+
+	if id != "<a1.2026-10-20@127.0.0.1>" {
+		t.Errorf("message ID = %q", id)
+	}
+	if srv.auth != "\x00user\x00pass" {
+		t.Errorf("auth = %q", srv.auth)
+	}
+	if !strings.Contains(srv.from, "<alerts@example.com>") || len(srv.to) != 1 || !strings.Contains(srv.to[0], "<golfer@example.com>") {
+		t.Errorf("envelope = %q %q", srv.from, srv.to)
+	}
+	for _, want := range []string{"To: golfer@example.com\r\n", "Subject: Tee times\r\n", "Message-ID: " + id + "\r\n", "7:10 AM\r\n8:00 AM"} {
+		if !strings.Contains(srv.data, want) {
+			t.Errorf("message missing %q:\n%s", want, srv.data)
+		}
+	}
+}
+
+func TestSMTPNotifierError(t *testing.T) {
+	var srv *fakeSMTP = startFakeSMTP(t, "nobody@example.com")
+	var notifier smtpNotifier = smtpNotifier{Addr: srv.addr, From: "alerts@example.com"}
+
+	_, err := notifier.Send(Notification{To: "nobody@example.com", Message: "hi"})
+	if err == nil || !strings.Contains(err.Error(), "No such user") {
+		t.Errorf("err = %v", err)
+	}
+
+	if _, err = (smtpNotifier{Addr: srv.addr}).Send(Notification{To: "golfer@example.com"}); err == nil {
+		t.Error("sent without SMTP_FROM")
+	}
+	if _, err = notifier.Send(Notification{To: "not an address"}); err == nil {
+		t.Error("sent to an invalid address")
+	}
+}
@@ -0,0 +1,307 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golf-teetimes/platforms"
+)
+
+// OutboxMessage is a notification waiting to be delivered. It is written in
+// the same transaction that records the alert as notified, so a crash can't
+// lose it. Delivery is at least once: a crash between sending and Complete
+// sends it again, with the same idempotency key.
+type OutboxMessage struct {
+	Key         string           `json:"key"` // idempotency key: alert ID + occurrence
+	AlertID     string           `json:"alertId"`
+	Channel     string           `json:"channel"`
+	To          string           `json:"to"`
+	Subject     string           `json:"subject"`
+	Message     string           `json:"message"`
+	Matches     []MatchedTeeTime `json:"matches,omitempty"`
+	MaxPrice    float64          `json:"maxPrice,omitempty"`
+	Ranked      bool             `json:"ranked,omitempty"` // from an area alert; see buildAlertMessage
+	Held        bool             `json:"held,omitempty"`   // delayed by the recipient's preferences
+	Attempts    int              `json:"attempts"`
+	NextAttempt string           `json:"nextAttempt"`
+	LastError   string           `json:"lastError,omitempty"`
+	CreatedAt   string           `json:"createdAt"`
+	DeadAt      string           `json:"deadAt,omitempty"`
+}
+
+// Outbox persists undelivered notifications alongside the alerts they belong to.
+type Outbox interface {
+	// Enqueue stores msg and applies update to its alert in one
+	// transaction. It reports false, without changing anything, when a
+	// message with the same key was already queued, sent or dead-lettered.
+	Enqueue(msg OutboxMessage, update func(a *platforms.Alert) error) (bool, error)
+
+	// Due returns queued messages whose next attempt is at or before now.
+	Due(now time.Time) ([]OutboxMessage, error)
+
+	// Complete removes a delivered message and remembers its key.
+	Complete(key string) error
+
+	// CancelTo removes every queued or held message for a recipient, e.g.
+	// after an SMS STOP, and remembers their keys like Complete.
+	CancelTo(channel string, to string) ([]OutboxMessage, error)
+
+	// Reschedule saves a failed message's attempt count and next attempt.
+	Reschedule(msg OutboxMessage) error
+
+	// DeadLetter moves a message that exhausted its attempts aside.
+	DeadLetter(msg OutboxMessage) error
+
+	// Pending and Dead list queued and dead-lettered messages.
+	Pending() ([]OutboxMessage, error)
+	Dead() ([]OutboxMessage, error)
+
+	// Requeue moves a dead-lettered message back into the queue.
+	Requeue(key string) error
+
+	// PruneSent forgets delivered keys older than the cutoff.
+	PruneSent(before time.Time) (int, error)
+}
+
+var outbox Outbox
+
+const (
+	outboxMaxAttempts  = 8
+	outboxBaseDelay    = 30 * time.Second
+	outboxMaxDelay     = 1 * time.Hour
+	outboxPollInterval = 10 * time.Second
+	outboxSentTTL      = 30 * 24 * time.Hour
+)
+
+// outboxWake nudges the worker to deliver right away instead of waiting for
+// the next poll.
+var outboxWake = make(chan struct{}, 1)
+
+func wakeOutbox() {
+	select {
+	case outboxWake <- struct{}{}:
+	default:
+	}
+}
+
+// notificationKey identifies one occurrence of an alert firing. One-shot and
+// recurring alerts fire once per date; continuous alerts once per
+// notification number. Edits re-arm an alert, so the key includes its
+// revision and an edited alert can fire again for the same dates.
+func notificationKey(a platforms.Alert, dates []string) string {
+	var id string = a.ID
+	if a.Revision > 0 {
+		id = fmt.Sprintf("%s.r%d", a.ID, a.Revision)
+	}
+	if a.Mode == platforms.AlertModeContinuous {
+		return fmt.Sprintf("%s:%d", id, a.NotificationCount+1)
+	}
+	return id + ":" + strings.Join(dates, ",")
+}
+
+// outboxBackoff returns the delay before the given (1-based) retry.
+func outboxBackoff(attempts int) time.Duration {
+	var delay time.Duration = outboxBaseDelay
+	for i := 1; i < attempts && delay < outboxMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > outboxMaxDelay {
+		delay = outboxMaxDelay
+	}
+	return delay
+}
+
+// deliverOutbox sends every message due now. Messages due for the same
+// recipient go out together, so a digest or the end of quiet hours yields
+// one message.
+func deliverOutbox(now time.Time) {
+	due, err := outbox.Due(now)
+	if err != nil {
+		fmt.Println("  [ERROR] Loading outbox:", err)
+		return
+	}
+	var batches map[string][]OutboxMessage = make(map[string][]OutboxMessage)
+	var order []string
+	for _, msg := range due {
+		var key string = msg.Channel + "|" + msg.To
+		if batches[key] == nil {
+			order = append(order, key)
+		}
+		batches[key] = append(batches[key], msg)
+	}
+	for _, key := range order {
+		deliverOutboxBatch(batches[key], now)
+	}
+}
+
+// deliverOutboxBatch sends one recipient's due messages. Text messages honor
+// the phone's quiet hours, daily cap and digest preferences; messages that
+// had to wait are re-checked so only tee times still open go out.
+func deliverOutboxBatch(batch []OutboxMessage, now time.Time) {
+	batch = dropStaleMessages(batch, now)
+	if len(batch) == 0 {
+		return
+	}
+	var first OutboxMessage = batch[0]
+	var loc *time.Location = time.Local
+
+	if first.Channel == ChannelSMS {
+		rec, err := phoneStore.GetPhone(first.To)
+		if err != nil {
+			fmt.Println("  [ERROR] Loading phone preferences:", err)
+			return
+		}
+		if rec.OptedOut {
+			for _, msg := range batch {
+				dropOutboxMessage(msg, "number opted out", now)
+			}
+			return
+		}
+		loc = rec.Prefs.location()
+
+		var digested bool
+		for _, msg := range batch {
+			digested = digested || msg.Held
+		}
+		var until time.Time = holdUntil(rec, now, digested)
+
+		// Past the service's daily cap or monthly budget, everything waits
+		blocked, reason, err := smsBlockedUntil(now)
+		if err != nil {
+			fmt.Println("  [ERROR] Loading SMS usage:", err)
+			return
+		}
+		if blocked.After(until) {
+			fmt.Println("  [WARN] Text messages suspended:", reason)
+			until = blocked
+		}
+
+		if !until.IsZero() {
+			for _, msg := range batch {
+				msg.Held = true
+				msg.NextAttempt = until.Format(time.RFC3339)
+				if err = outbox.Reschedule(msg); err != nil {
+					fmt.Println("  [ERROR] Holding outbox message:", err)
+				}
+			}
+			fmt.Println("  Holding", len(batch), "message(s) for", first.To, "until", until.In(loc).Format("Jan 2 3:04 PM"))
+			return
+		}
+	}
+
+	var sendable []OutboxMessage
+	for _, msg := range batch {
+		if msg.Held {
+			msg.Matches = stillOpen(msg.Matches, now)
+			if len(msg.Matches) == 0 {
+				fmt.Println("  Dropping held message for alert", msg.AlertID, "— tee times gone")
+				logDelivery(msg, DeliveryDropped, "", nil, now)
+				if err := outbox.Complete(msg.Key); err != nil {
+					fmt.Println("  [ERROR] Completing outbox message:", err)
+				}
+				continue
+			}
+			msg.Message = buildAlertMessage(msg.Matches, msg.MaxPrice, msg.Ranked)
+		}
+		sendable = append(sendable, msg)
+	}
+	if len(sendable) == 0 {
+		return
+	}
+
+	var n Notification = Notification{
+		AlertID:        sendable[0].AlertID,
+		IdempotencyKey: sendable[0].Key,
+		To:             first.To,
+		Subject:        sendable[0].Subject,
+		Message:        sendable[0].Message,
+		Matches:        sendable[0].Matches,
+	}
+	for _, msg := range sendable[1:] {
+		n.Subject = "Tee time alerts"
+		n.Message += "\n\n" + msg.Message
+		n.Matches = append(n.Matches, msg.Matches...)
+	}
+
+	var providerID string
+	notifier, err := notifierFor(first.Channel)
+	if err == nil {
+		providerID, err = notifier.Send(n)
+	}
+
+	if err == nil {
+		fmt.Println("  ✓", first.Channel, "sent for", len(sendable), "alert(s) to", first.To)
+		for _, msg := range sendable {
+			logDelivery(msg, DeliverySent, providerID, nil, now)
+			if err = outbox.Complete(msg.Key); err != nil {
+				fmt.Println("  [ERROR] Completing outbox message:", err)
+			}
+		}
+		if first.Channel == ChannelSMS {
+			recordSent(first.To, now)
+		}
+		return
+	}
+
+	for _, msg := range sendable {
+		retryOutboxMessage(msg, err, now)
+	}
+}
+
+// dropStaleMessages re-reads each message's alert just before delivery and
+// drops messages whose alert was deleted, paused by STOP or is waiting on
+// verification again. If the alert can't be read the message waits.
+func dropStaleMessages(batch []OutboxMessage, now time.Time) []OutboxMessage {
+	var kept []OutboxMessage
+	for _, msg := range batch {
+		a, err := alertStore.Get(msg.AlertID)
+		switch {
+		case err == ErrAlertNotFound:
+			dropOutboxMessage(msg, "alert deleted", now)
+		case err != nil:
+			fmt.Println("  [ERROR] Loading alert for outbox message:", err)
+		case a.OptedOut:
+			dropOutboxMessage(msg, "alert paused", now)
+		case a.Pending:
+			dropOutboxMessage(msg, "alert awaiting verification", now)
+		default:
+			kept = append(kept, msg)
+		}
+	}
+	return kept
+}
+
+func dropOutboxMessage(msg OutboxMessage, reason string, now time.Time) {
+	fmt.Println("  Dropping message for alert", msg.AlertID, "—", reason)
+	logDelivery(msg, DeliveryDropped, "", errors.New(reason), now)
+	if err := outbox.Complete(msg.Key); err != nil {
+		fmt.Println("  [ERROR] Completing outbox message:", err)
+	}
+}
+
+func retryOutboxMessage(msg OutboxMessage, sendErr error, now time.Time) {
+	var err error
+	var status string = DeliveryFailed
+	if msg.Attempts+1 >= outboxMaxAttempts {
+		status = DeliveryDead
+	}
+	logDelivery(msg, status, "", sendErr, now)
+
+	msg.Attempts++
+	msg.LastError = sendErr.Error()
+	if msg.Attempts >= outboxMaxAttempts {
+		fmt.Println("  [ERROR]", msg.Channel, "failed for alert", msg.AlertID, "— dead-lettered after", msg.Attempts, "attempts:", sendErr)
+		msg.DeadAt = now.Format(time.RFC3339)
+		err = outbox.DeadLetter(msg)
+	} else {
+		var delay time.Duration = outboxBackoff(msg.Attempts)
+		fmt.Println("  [ERROR]", msg.Channel, "failed for alert", msg.AlertID, "— retrying in", delay, ":", sendErr)
+		msg.NextAttempt = now.Add(delay).Format(time.RFC3339)
+		err = outbox.Reschedule(msg)
+	}
+	if err != nil {
+		fmt.Println("  [ERROR] Saving outbox message:", err)
+	}
+}
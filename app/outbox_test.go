@@ -0,0 +1,112 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"golf-teetimes/platforms"
+)
+
+func TestOutboxBackoff(t *testing.T) {
+	var cases map[int]time.Duration = map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		3:  2 * time.Minute,
+		7:  32 * time.Minute,
+		8:  time.Hour,
+		20: time.Hour,
+	}
+	for attempts, want := range cases {
+		if got := outboxBackoff(attempts); got != want {
+			t.Errorf("outboxBackoff(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
+
+func TestRetryOutboxMessageDeadLetters(t *testing.T) {
+	useTestStore(t)
+	var now time.Time = time.Now().Truncate(time.Second)
+	var msg OutboxMessage = OutboxMessage{Key: "a1:2026-10-20", AlertID: "a1", Channel: ChannelSMS, To: "+13035550100", Attempts: outboxMaxAttempts - 2, NextAttempt: now.Format(time.RFC3339)}
+	if _, err := outbox.Enqueue(msg, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	retryOutboxMessage(msg, errors.New("twilio API error 500"), now)
+	pending, err := outbox.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 {
+		t.Fatalf("pending = %d messages, want 1", len(pending))
+	}
+	msg = pending[0]
+	if msg.Attempts != outboxMaxAttempts-1 || msg.LastError != "twilio API error 500" {
+		t.Errorf("rescheduled message = %+v", msg)
+	}
+	if want := now.Add(outboxBackoff(outboxMaxAttempts - 1)).Format(time.RFC3339); msg.NextAttempt != want {
+		t.Errorf("NextAttempt = %s, want %s", msg.NextAttempt, want)
+	}
+
+	retryOutboxMessage(msg, errors.New("twilio API error 500"), now)
+	if pending, _ = outbox.Pending(); len(pending) != 0 {
+		t.Errorf("message still queued after %d attempts", outboxMaxAttempts)
+	}
+	dead, err := outbox.Dead()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dead) != 1 || dead[0].Attempts != outboxMaxAttempts || dead[0].DeadAt == "" {
+		t.Errorf("dead = %+v", dead)
+	}
+}
+
+func TestEnqueueDedupesOnNotificationKey(t *testing.T) {
+	useTestStore(t)
+	var a platforms.Alert = platforms.Alert{ID: "a1", Phone: "+13035550100", Metro: "denver", Date: "2026-10-20", StartTime: "7:00 AM", EndTime: "11:00 AM"}
+	if err := alertStore.Create(a, nil); err != nil {
+		t.Fatal(err)
+	}
+	var dates []string = []string{"2026-10-20"}
+	notified := func(a *platforms.Alert) error {
+		a.NotificationCount++
+		return nil
+	}
+	enqueue := func(a platforms.Alert) bool {
+		t.Helper()
+		queued, err := outbox.Enqueue(OutboxMessage{Key: notificationKey(a, dates), AlertID: a.ID, Channel: ChannelSMS, To: a.Phone}, notified)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return queued
+	}
+
+	if !enqueue(a) {
+		t.Fatal("first notification wasn't queued")
+	}
+	if enqueue(a) {
+		t.Error("same notification queued twice")
+	}
+	if err := outbox.Complete(notificationKey(a, dates)); err != nil {
+		t.Fatal(err)
+	}
+	if enqueue(a) {
+		t.Error("delivered notification queued again")
+	}
+	stored, err := alertStore.Get(a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.NotificationCount != 1 {
+		t.Errorf("NotificationCount = %d, want 1; duplicates must not update the alert", stored.NotificationCount)
+	}
+
+	// Editing the alert bumps its revision, which re-arms it for the same dates
+	a.Revision = 1
+	if notificationKey(a, dates) != "a1.r1:2026-10-20" {
+		t.Errorf("key = %s", notificationKey(a, dates))
+	}
+	if !enqueue(a) {
+		t.Error("edited alert wasn't re-armed")
+	}
+}
@@ -0,0 +1,244 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golf-teetimes/platforms"
+)
+
+// NotificationPrefs are a phone's delivery preferences. Times are "HH:MM" in
+// Timezone; a quiet window may wrap past midnight (e.g. 21:00–07:00).
+type NotificationPrefs struct {
+	Timezone      string `json:"timezone,omitempty"`
+	QuietStart    string `json:"quietStart,omitempty"`
+	QuietEnd      string `json:"quietEnd,omitempty"`
+	MaxPerDay     int    `json:"maxPerDay,omitempty"`     // 0 = no cap
+	DigestMinutes int    `json:"digestMinutes,omitempty"` // 0 = send each match right away
+}
+
+const defaultPrefsTimezone = "America/Denver"
+
+func (p NotificationPrefs) location() *time.Location {
+	var tz string = p.Timezone
+	if tz == "" {
+		tz = defaultPrefsTimezone
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// parseClock converts "HH:MM" to minutes past midnight.
+func parseClock(s string) (int, bool) {
+	hh, mm, found := strings.Cut(s, ":")
+	if !found {
+		return 0, false
+	}
+	h, err1 := strconv.Atoi(hh)
+	m, err2 := strconv.Atoi(mm)
+	if err1 != nil || err2 != nil || h < 0 || h > 23 || m < 0 || m > 59 {
+		return 0, false
+	}
+	return h*60 + m, true
+}
+
+func validatePrefs(p NotificationPrefs) error {
+	if p.Timezone != "" {
+		if _, err := time.LoadLocation(p.Timezone); err != nil {
+			return errors.New("Unknown timezone: " + p.Timezone)
+		}
+	}
+	if (p.QuietStart == "") != (p.QuietEnd == "") {
+		return errors.New("Set both the start and end of quiet hours.")
+	}
+	if p.QuietStart != "" {
+		if _, ok := parseClock(p.QuietStart); !ok {
+			return errors.New("Quiet hours must look like 21:00.")
+		}
+		if _, ok := parseClock(p.QuietEnd); !ok {
+			return errors.New("Quiet hours must look like 07:00.")
+		}
+	}
+	if p.MaxPerDay < 0 || p.DigestMinutes < 0 {
+		return errors.New("Limits can't be negative.")
+	}
+	if p.DigestMinutes > 24*60 {
+		return errors.New("Digests go out at least once a day.")
+	}
+	return nil
+}
+
+// quietUntil returns when the phone's quiet hours end, or the zero time if
+// now isn't inside them.
+func quietUntil(p NotificationPrefs, now time.Time) time.Time {
+	start, ok1 := parseClock(p.QuietStart)
+	end, ok2 := parseClock(p.QuietEnd)
+	if !ok1 || !ok2 || start == end {
+		return time.Time{}
+	}
+
+	var local time.Time = now.In(p.location())
+	var mins int = local.Hour()*60 + local.Minute()
+	var midnight time.Time = time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+
+	if start < end {
+		if mins >= start && mins < end {
+			return midnight.Add(time.Duration(end) * time.Minute)
+		}
+		return time.Time{}
+	}
+	// Window wraps past midnight
+	if mins >= start {
+		return midnight.AddDate(0, 0, 1).Add(time.Duration(end) * time.Minute)
+	}
+	if mins < end {
+		return midnight.Add(time.Duration(end) * time.Minute)
+	}
+	return time.Time{}
+}
+
+// holdUntil returns when a message to this phone may go out, or the zero
+// time if it can be sent now. digested reports whether the batch already
+// waited for its digest slot.
+func holdUntil(rec PhoneRecord, now time.Time, digested bool) time.Time {
+	var p NotificationPrefs = rec.Prefs
+	var until time.Time
+
+	if phoneCapReached(rec, now) {
+		var local time.Time = now.In(p.location())
+		until = time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location()).AddDate(0, 0, 1)
+	}
+	if p.DigestMinutes > 0 && !digested && until.IsZero() {
+		// Digest slots are counted from local midnight so a daily digest
+		// lands at midnight rather than at a UTC boundary
+		var local time.Time = now.In(p.location())
+		var midnight time.Time = time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+		var interval time.Duration = time.Duration(p.DigestMinutes) * time.Minute
+		until = midnight.Add(now.Sub(midnight).Truncate(interval) + interval)
+	}
+
+	// Whatever the reason, never deliver inside quiet hours
+	var check time.Time = now
+	if !until.IsZero() {
+		check = until
+	}
+	if quiet := quietUntil(p, check); !quiet.IsZero() {
+		until = quiet
+	}
+	return until
+}
+
+// stillOpen keeps the matches whose tee time is in the future and still
+// listed with openings, re-fetching each course and date once. Tee times are
+// read in the course's own timezone, not the recipient's.
+func stillOpen(matches []MatchedTeeTime, now time.Time) []MatchedTeeTime {
+	var fresh map[string][]platforms.DisplayTeeTime = make(map[string][]platforms.DisplayTeeTime)
+	var open []MatchedTeeTime
+	for _, m := range matches {
+		entry, ok := platforms.FindCourse(m.Course)
+		if !ok {
+			continue
+		}
+		teeTime, err := time.ParseInLocation("2006-01-02 3:04 PM", m.Date+" "+m.Time, metroLocation(entry.Metro))
+		if err != nil || !teeTime.After(now) {
+			continue
+		}
+		var key string = entry.Key + ":" + m.Date
+		teeTimes, fetched := fresh[key]
+		if !fetched {
+			if cached, _, ok := cachedMetroTeeTimes(entry.Metro, m.Date); ok {
+				teeTimes = cached
+			} else {
+				teeTimes, _ = entry.Fetch(m.Date)
+			}
+			fresh[key] = teeTimes
+		}
+
+		for _, tt := range teeTimes {
+			if getBaseCourse(tt.Course) == m.Course && tt.Time == m.Time && tt.Openings > 0 {
+				open = append(open, m)
+				break
+			}
+		}
+	}
+	return open
+}
+
+// phoneCapReached reports whether the number has had its daily cap of texts.
+func phoneCapReached(rec PhoneRecord, now time.Time) bool {
+	var limit int = smsPhoneDailyLimit(rec.Prefs)
+	return limit > 0 && rec.SentDay == now.In(rec.Prefs.location()).Format("2006-01-02") && rec.SentCount >= limit
+}
+
+// recordSent counts a delivered message toward the phone's daily cap.
+func recordSent(phone string, now time.Time) {
+	_, err := phoneStore.UpdatePhone(phone, func(p *PhoneRecord) error {
+		var day string = now.In(p.Prefs.location()).Format("2006-01-02")
+		if p.SentDay != day {
+			p.SentDay = day
+			p.SentCount = 0
+		}
+		p.SentCount++
+		return nil
+	})
+	if err != nil {
+		fmt.Println("  [ERROR] Recording sent message:", err)
+	}
+}
+
+func handlePrefs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	owner, err := requestOwner(r)
+	if err != nil {
+		w.WriteHeader(401)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if !strings.HasPrefix(owner, "+") {
+		w.WriteHeader(400)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Preferences are only available for phone numbers."})
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		rec, err := phoneStore.GetPhone(owner)
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		json.NewEncoder(w).Encode(rec.Prefs)
+	case "POST":
+		var prefs NotificationPrefs
+		if err = json.NewDecoder(r.Body).Decode(&prefs); err != nil {
+			w.WriteHeader(400)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body."})
+			return
+		}
+		if err = validatePrefs(prefs); err != nil {
+			w.WriteHeader(400)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		_, err = phoneStore.UpdatePhone(owner, func(p *PhoneRecord) error {
+			p.Prefs = prefs
+			return nil
+		})
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		json.NewEncoder(w).Encode(prefs)
+	default:
+		http.Error(w, "Method not allowed", 405)
+	}
+}
@@ -0,0 +1,210 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"golf-teetimes/platforms"
+)
+
+// previewMaxDates caps how many of a repeating alert's dates a preview
+// fetches.
+const previewMaxDates = 3
+
+// ExcludedTeeTime is a tee time at one of the alert's courses that a filter
+// ruled out.
+type ExcludedTeeTime struct {
+	MatchedTeeTime
+	Filter string `json:"filter"` // openings, players, holes, price or time
+	Reason string `json:"reason"`
+}
+
+// OpeningStats says how often the alert's courses have had openings in its
+// window. The figures come from the checker's opening tallies, so courses no
+// alert has watched yet have no history.
+type OpeningStats struct {
+	Sheets             int `json:"sheets"`             // past tee sheets watched, up to openingTallyTTL back
+	SheetsWithOpenings int `json:"sheetsWithOpenings"` // of those, where a slot in the window opened up
+	RecentOpenings     int `json:"recentOpenings"`     // slots in the window that opened in the last day
+}
+
+type AlertPreview struct {
+	Dates          []string          `json:"dates"`
+	Unreleased     []string          `json:"unreleased,omitempty"` // dates not yet on sale
+	Matches        []MatchedTeeTime  `json:"matches"`
+	Excluded       []ExcludedTeeTime `json:"excluded"`
+	ExcludedCounts map[string]int    `json:"excludedCounts"`
+	Typical        OpeningStats      `json:"typical"`
+}
+
+// previewAlert reports what an alert would match right now and why other
+// tee times at its courses wouldn't, without saving anything.
+func previewAlert(a platforms.Alert, now time.Time) (AlertPreview, error) {
+	var preview AlertPreview = AlertPreview{
+		Dates:          []string{},
+		Matches:        []MatchedTeeTime{},
+		Excluded:       []ExcludedTeeTime{},
+		ExcludedCounts: make(map[string]int),
+	}
+	if err := validateAlertFilters(&a); err != nil {
+		return preview, err
+	}
+
+	var entries []*platforms.CourseEntry = alertEntries(a)
+	var startMins int = parseTimeToMinutes(a.StartTime)
+	var endMins int = parseTimeToMinutes(a.EndTime)
+
+	var dates []string = alertOccurrences(a, now)
+	if len(dates) > previewMaxDates {
+		dates = dates[:previewMaxDates]
+	}
+	for _, date := range dates {
+		preview.Dates = append(preview.Dates, date)
+
+		// Fetch per metro, like the checker, skipping courses not on sale yet
+		var byMetro map[string][]*platforms.CourseEntry = make(map[string][]*platforms.CourseEntry)
+		var unreleased bool
+		for _, e := range entries {
+			if deferred, _ := releaseState(e, date, now); deferred {
+				unreleased = true
+				continue
+			}
+			byMetro[e.Metro] = append(byMetro[e.Metro], e)
+		}
+		if unreleased {
+			preview.Unreleased = append(preview.Unreleased, date)
+		}
+
+		for metro, metroEntries := range byMetro {
+			teeTimes, _, ok := cachedMetroTeeTimes(metro, date)
+			if !ok {
+				teeTimes = fetchCourseEntries(metroEntries, date, checkerFetchWorkers)
+			}
+			for _, tt := range teeTimes {
+				if !alertCoversTeeTime(a, tt) {
+					continue
+				}
+				var m MatchedTeeTime = MatchedTeeTime{
+					Course:   getBaseCourse(tt.Course),
+					Date:     date,
+					Time:     tt.Time,
+					Openings: tt.Openings,
+					Price:    tt.Price,
+					Holes:    tt.Holes,
+				}
+				filter, reason := teeTimeExclusion(a, tt, startMins, endMins)
+				if filter == "" {
+					preview.Matches = append(preview.Matches, m)
+					continue
+				}
+				preview.Excluded = append(preview.Excluded, ExcludedTeeTime{MatchedTeeTime: m, Filter: filter, Reason: reason})
+				preview.ExcludedCounts[filter]++
+			}
+		}
+	}
+
+	stats, err := openingStats(a, entries, now)
+	if err != nil {
+		return preview, err
+	}
+	preview.Typical = stats
+	return preview, nil
+}
+
+// openingStats counts, across the past tee sheets of the alert's courses, the
+// ones where a slot in the alert's window opened up for enough players, and
+// the slots in that window that opened up in the last day.
+func openingStats(a platforms.Alert, entries []*platforms.CourseEntry, now time.Time) (OpeningStats, error) {
+	var stats OpeningStats
+	var startMins int = parseTimeToMinutes(a.StartTime)
+	var endMins int = parseTimeToMinutes(a.EndTime)
+	var need int = a.MinPlayers
+	if need < 1 {
+		need = 1
+	}
+	inWindow := func(teeTime string, holes string) bool {
+		var mins int = parseTimeToMinutes(teeTime)
+		if mins < startMins || mins > endMins {
+			return false
+		}
+		return a.Holes == "" || a.Holes == "0" || holes == "" || holes == a.Holes
+	}
+
+	var since string = now.Add(-openingTallyTTL).Format("2006-01-02")
+	var today string = now.Format("2006-01-02")
+	var watched map[string]bool = make(map[string]bool)
+	for _, e := range entries {
+		watched[e.Key] = true
+		tallies, err := slotStore.OpeningTallies(e.Key, since)
+		if err != nil {
+			return stats, err
+		}
+		for date, tally := range tallies {
+			// Today's and later sheets are still filling in
+			if date >= today {
+				continue
+			}
+			stats.Sheets++
+			for key, openings := range tally {
+				// slotKey is course|time|holes; course names may hold anything
+				var parts []string = strings.Split(key, "|")
+				if len(parts) < 3 || openings < need {
+					continue
+				}
+				if inWindow(parts[len(parts)-2], parts[len(parts)-1]) {
+					stats.SheetsWithOpenings++
+					break
+				}
+			}
+		}
+	}
+
+	events, err := slotStore.SlotEvents(now.Add(-slotEventTTL))
+	if err != nil {
+		return stats, err
+	}
+	for _, ev := range events {
+		if !watched[ev.CourseKey] || ev.Openings < need || !inWindow(ev.Time, ev.Holes) {
+			continue
+		}
+		if a.MaxPrice > 0 && (ev.Price <= 0 || ev.Price > a.MaxPrice) {
+			continue
+		}
+		stats.RecentOpenings++
+	}
+	return stats, nil
+}
+
+// handlePreviewAlert takes the same body as /api/alerts/create and returns
+// what the alert would match now. Contact details aren't required.
+func handlePreviewAlert(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	// A preview fetches tee sheets, so it's limited like alert creation
+	if alertRateLimited(r) {
+		w.WriteHeader(429)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Too many requests. Please try again in a minute."})
+		return
+	}
+
+	var incoming platforms.Alert
+	if err := json.NewDecoder(r.Body).Decode(&incoming); err != nil {
+		w.WriteHeader(400)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body."})
+		return
+	}
+
+	preview, err := previewAlert(incoming, time.Now())
+	if err != nil {
+		w.WriteHeader(400)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	json.NewEncoder(w).Encode(preview)
+}
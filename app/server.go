@@ -0,0 +1,110 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Serve runs the web server, and the alert worker alongside it, until SIGINT
+// or SIGTERM. With ALERT_WORKER=off it leaves the worker to a separate
+// alert-worker process.
+func Serve() {
+	loadTemplates()
+
+	http.HandleFunc("/privacy", handlePrivacy)
+	http.HandleFunc("/terms", handleTerms)
+	http.HandleFunc("/api/alerts", handleGetAlerts)
+	http.HandleFunc("/api/alerts/create", handleCreateAlert)
+	http.HandleFunc("/api/alerts/preview", handlePreviewAlert)
+	http.HandleFunc("/api/alerts/update", handleUpdateAlert)
+	http.HandleFunc("/api/alerts/delete", handleDeleteAlert)
+	http.HandleFunc("/api/alerts/history", handleAlertHistory)
+	http.HandleFunc("/api/alerts/link", handleManageLink)
+	http.HandleFunc("/api/prefs", handlePrefs)
+	http.HandleFunc("/optin", handleOptIn)
+	http.HandleFunc("/api/sms/inbound", handleInboundSMS)
+	http.HandleFunc("/api/phone/code", handleSendVerificationCode)
+	http.HandleFunc("/api/phone/verify", handleVerifyPhone)
+	http.HandleFunc("/admin/outbox", handleAdminOutbox)
+	http.HandleFunc("/admin/outbox/retry", handleAdminOutboxRetry)
+	http.HandleFunc("/admin/usage", handleAdminUsage)
+	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
+	http.HandleFunc("/", handleRouting)
+
+	store, err := openStores()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer store.Close()
+	warnTrustedProxies()
+
+	var worker *alertWorker
+	if os.Getenv("ALERT_WORKER") != "off" {
+		worker = newAlertWorker(store)
+		worker.Start()
+	}
+
+	srv := &http.Server{Addr: ":8080"}
+
+	go func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+		<-sigCh
+		fmt.Println("Shutting down gracefully...")
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+
+	fmt.Println("Server running at http://localhost:8080")
+	err = srv.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
+		fmt.Println("Server failed:", err)
+	}
+	if worker != nil {
+		worker.Stop()
+	}
+}
+
+func handleRouting(w http.ResponseWriter, r *http.Request) {
+	var path string = strings.Trim(r.URL.Path, "/")
+
+	if path == "" {
+		handleLanding(w, r)
+		return
+	}
+
+	if strings.HasPrefix(path, "railway-verify=") {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(path))
+		return
+	}
+
+	var parts []string = strings.SplitN(path, "/", 2)
+	var metro Metro
+	var exists bool
+	metro, exists = Metros[parts[0]]
+	if !exists {
+		http.NotFound(w, r)
+		return
+	}
+
+	if len(parts) == 1 {
+		handleMetroHome(w, r, metro)
+	} else if parts[1] == "teetimes" {
+		handleMetroTeeTimes(w, r, metro)
+	} else if parts[1] == "alerts" {
+		handleMetroAlerts(w, r, metro)
+	} else if parts[1] == "openings" {
+		handleMetroOpenings(w, r, metro)
+	} else {
+		http.NotFound(w, r)
+	}
+}
@@ -0,0 +1,192 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"golf-teetimes/platforms"
+)
+
+// SlotSnapshot is one course's tee sheet on one date as of a poll: openings
+// per slot, including slots that were full.
+type SlotSnapshot struct {
+	Taken string         `json:"taken"`
+	Slots map[string]int `json:"slots"` // slotKey → openings
+}
+
+// SlotEvent is new availability: a slot that was full or missing at the
+// previous poll, or gained openings since, most often a cancellation.
+type SlotEvent struct {
+	At           string  `json:"at"`
+	Metro        string  `json:"metro"`
+	CourseKey    string  `json:"courseKey"`
+	Course       string  `json:"course"` // base display name, as in MatchedTeeTime
+	City         string  `json:"city,omitempty"`
+	Date         string  `json:"date"`
+	Time         string  `json:"time"`
+	Holes        string  `json:"holes,omitempty"`
+	Openings     int     `json:"openings"`
+	PrevOpenings int     `json:"prevOpenings"`
+	Price        float64 `json:"price,omitempty"`
+	BookingURL   string  `json:"bookingUrl,omitempty"`
+}
+
+// OpeningTally is what opened up on one course's tee sheet for one date:
+// slotKey → the most openings the slot had when it opened. A sheet that was
+// watched but never had anything open up has an empty tally.
+type OpeningTally map[string]int
+
+// SlotStore keeps the latest snapshot per course and date, recent
+// availability events, and a longer-lived tally of openings per sheet.
+type SlotStore interface {
+	// SwapSnapshot saves snap for the course and date and returns the one it
+	// replaced (nil if none). A snapshot taken before the saved one is
+	// ignored, and saved is false. Slots that gained openings are added to
+	// the sheet's tally in the same transaction.
+	SwapSnapshot(courseKey string, date string, snap SlotSnapshot) (prev *SlotSnapshot, saved bool, err error)
+
+	AddSlotEvents(events []SlotEvent) error
+
+	// SlotEvents returns events at or after since, oldest first.
+	SlotEvents(since time.Time) ([]SlotEvent, error)
+
+	// PruneSlots forgets events older than before and snapshots of dates
+	// before its day.
+	PruneSlots(before time.Time) (int, error)
+
+	// OpeningTallies returns a course's tallies by date, for dates from
+	// since (YYYY-MM-DD) on.
+	OpeningTallies(courseKey string, since string) (map[string]OpeningTally, error)
+
+	// PruneOpeningTallies forgets tallies of dates before the YYYY-MM-DD.
+	PruneOpeningTallies(before string) (int, error)
+}
+
+var slotStore SlotStore
+
+const (
+	slotEventTTL       = 24 * time.Hour
+	openingTallyTTL    = 8 * 7 * 24 * time.Hour
+	slotFeedDefaultAge = 2 * time.Hour
+	slotFeedMaxEvents  = 200
+)
+
+func slotKey(tt platforms.DisplayTeeTime) string {
+	return tt.Course + "|" + tt.Time + "|" + tt.Holes
+}
+
+// recordSlotSnapshots diffs a fetch for one metro and date against the
+// previous snapshot of each watched course and stores the new availability
+// it finds. The first snapshot of a course and date is only a baseline.
+func recordSlotSnapshots(entries []*platforms.CourseEntry, date string, teeTimes []platforms.DisplayTeeTime, taken time.Time) []SlotEvent {
+	var events []SlotEvent
+	for _, e := range entries {
+		var sheet []platforms.DisplayTeeTime
+		for _, tt := range teeTimes {
+			if e.Match(tt.Course) || e.Match(getBaseCourse(tt.Course)) {
+				sheet = append(sheet, tt)
+			}
+		}
+		// A failed fetch and a sold-out sheet both come back empty; keep the
+		// last real snapshot rather than call every slot new next time
+		if len(sheet) == 0 {
+			continue
+		}
+
+		var snap SlotSnapshot = SlotSnapshot{Taken: taken.Format(time.RFC3339Nano), Slots: make(map[string]int)}
+		for _, tt := range sheet {
+			snap.Slots[slotKey(tt)] = tt.Openings
+		}
+		prev, saved, err := slotStore.SwapSnapshot(e.Key, date, snap)
+		if err != nil {
+			fmt.Println("  [ERROR] Saving slot snapshot:", err)
+			continue
+		}
+		if !saved || prev == nil {
+			continue
+		}
+
+		for _, tt := range sheet {
+			var before int = prev.Slots[slotKey(tt)]
+			if tt.Openings <= before {
+				continue
+			}
+			events = append(events, SlotEvent{
+				At:           taken.Format(time.RFC3339),
+				Metro:        e.Metro,
+				CourseKey:    e.Key,
+				Course:       getBaseCourse(tt.Course),
+				City:         tt.City,
+				Date:         date,
+				Time:         tt.Time,
+				Holes:        tt.Holes,
+				Openings:     tt.Openings,
+				PrevOpenings: before,
+				Price:        tt.Price,
+				BookingURL:   tt.BookingURL,
+			})
+		}
+	}
+
+	if len(events) > 0 {
+		fmt.Printf("  %d new opening(s) on %s\n", len(events), date)
+		if err := slotStore.AddSlotEvents(events); err != nil {
+			fmt.Println("  [ERROR] Saving slot events:", err)
+		}
+	}
+	return events
+}
+
+// newSlotKeys indexes events by teeTimeKey so they can be checked against
+// an alert's matches.
+func newSlotKeys(events []SlotEvent) map[string]bool {
+	var keys map[string]bool = make(map[string]bool)
+	for _, ev := range events {
+		keys[teeTimeKey(MatchedTeeTime{Course: ev.Course, Date: ev.Date, Time: ev.Time, Holes: ev.Holes})] = true
+	}
+	return keys
+}
+
+// handleMetroOpenings is the public feed of a metro's recently opened tee
+// times, newest first. ?since= (RFC3339) narrows it; the default is the last
+// two hours.
+func handleMetroOpenings(w http.ResponseWriter, r *http.Request, metro Metro) {
+	var since time.Time = time.Now().Add(-slotFeedDefaultAge)
+	if s := r.URL.Query().Get("since"); s != "" {
+		parsed, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			http.Error(w, "Invalid since", 400)
+			return
+		}
+		since = parsed
+	}
+	var limit int = slotFeedMaxEvents
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l < limit {
+		limit = l
+	}
+
+	events, err := slotStore.SlotEvents(since)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	var feed []SlotEvent = []SlotEvent{}
+	for _, ev := range events {
+		if ev.Metro == metro.Slug {
+			feed = append(feed, ev)
+		}
+	}
+	sort.SliceStable(feed, func(i, j int) bool {
+		return feed[i].At > feed[j].At
+	})
+	if len(feed) > limit {
+		feed = feed[:limit]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(feed)
+}
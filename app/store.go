@@ -0,0 +1,229 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"golf-teetimes/platforms"
+)
+
+// AlertsFile is the legacy flat-file store, imported once into the database.
+const AlertsFile = "alerts.json"
+
+// AlertsDBFile is the embedded database holding alerts and checker state.
+const AlertsDBFile = "data/alerts.db"
+
+var ErrAlertNotFound = errors.New("Alert not found")
+
+// AlertStore persists alerts. Every method runs in its own transaction, so a
+// write never clobbers changes made by a concurrent request or checker pass.
+type AlertStore interface {
+	List() ([]platforms.Alert, error)
+	Get(id string) (platforms.Alert, error)
+	// ByPhone returns the alerts owned by a phone number, or by a contact
+	// address for alerts delivered without SMS.
+	ByPhone(phone string) ([]platforms.Alert, error)
+
+	// Create inserts the alert. check runs inside the same transaction with
+	// the phone's existing alerts and can veto the insert (e.g. duplicates).
+	Create(alert platforms.Alert, check func(existing []platforms.Alert) error) error
+
+	// Update applies fn to the stored alert and saves it atomically.
+	Update(id string, fn func(a *platforms.Alert) error) (platforms.Alert, error)
+
+	// Delete removes the alert if check (when non-nil) allows it.
+	Delete(id string, check func(a platforms.Alert) error) error
+
+	// PruneBefore deletes alerts whose date (or range or recurrence end
+	// date) is before the given YYYY-MM-DD.
+	PruneBefore(date string) (int, error)
+
+	Close() error
+}
+
+var alertStore AlertStore
+
+// openAlertStore opens the database and imports the legacy JSON file the
+// first time it finds one. The returned store also serves as the Outbox and
+// PhoneStore.
+func openAlertStore() (*boltAlertStore, error) {
+	err := os.MkdirAll("data", 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	var store *boltAlertStore
+	store, err = openBoltAlertStore(AlertsDBFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var imported int
+	imported, err = importAlertsJSON(store, AlertsFile)
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("importing %s: %w", AlertsFile, err)
+	}
+	if imported > 0 {
+		fmt.Println("Imported", imported, "alerts from", AlertsFile)
+	}
+
+	err = normalizeAlertPhones(store)
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("normalizing phone numbers: %w", err)
+	}
+
+	return store, nil
+}
+
+// openStores opens the database and points every store at it, then loads the
+// signing key. The web server and alert-worker both start here.
+func openStores() (*boltAlertStore, error) {
+	store, err := openAlertStore()
+	if err != nil {
+		return nil, fmt.Errorf("Opening alert store failed: %w", err)
+	}
+	alertStore = store
+	outbox = store
+	phoneStore = store
+	notificationLog = store
+	slotStore = store
+	usageStore = store
+	if err = loadManageKey(store); err != nil {
+		store.Close()
+		return nil, fmt.Errorf("Loading signing key failed: %w", err)
+	}
+	return store, nil
+}
+
+// importAlertsJSON copies alerts from the legacy file into the store, then
+// renames the file so the import never runs twice. Alerts already present
+// (same ID) are skipped.
+func importAlertsJSON(store AlertStore, path string) (int, error) {
+	var data []byte
+	var err error
+	data, err = os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	var alerts []platforms.Alert
+	err = json.Unmarshal(data, &alerts)
+	if err != nil {
+		return 0, err
+	}
+
+	var imported int
+	for _, a := range alerts {
+		if _, err = store.Get(a.ID); err == nil {
+			continue
+		}
+		err = store.Create(a, nil)
+		if err != nil {
+			return imported, err
+		}
+		imported++
+	}
+
+	return imported, os.Rename(path, path+".imported")
+}
+
+// PhoneRecord holds per-number state that outlives any single alert.
+type PhoneRecord struct {
+	Phone      string `json:"phone"`
+	OptedOut   bool   `json:"optedOut,omitempty"`
+	OptedOutAt string `json:"optedOutAt,omitempty"`
+
+	// Verification: a number is verified once it confirms a texted code,
+	// until phoneVerificationTTL passes. Only the code's hash is kept.
+	VerifiedAt   string `json:"verifiedAt,omitempty"`
+	CodeHash     string `json:"codeHash,omitempty"`
+	CodeSentAt   string `json:"codeSentAt,omitempty"`
+	CodeExpires  string `json:"codeExpires,omitempty"`
+	CodeAttempts int    `json:"codeAttempts,omitempty"`
+	// CodeAlerts are the pending alerts the current code was sent for; a
+	// correct code turns on only these
+	CodeAlerts []string `json:"codeAlerts,omitempty"`
+
+	Prefs     NotificationPrefs `json:"prefs"`
+	SentDay   string            `json:"sentDay,omitempty"` // local date SentCount applies to
+	SentCount int               `json:"sentCount,omitempty"`
+}
+
+// PhoneStore persists PhoneRecords keyed by E.164 number.
+type PhoneStore interface {
+	// GetPhone returns the number's record, or a zero record if none exists.
+	GetPhone(phone string) (PhoneRecord, error)
+
+	// UpdatePhone applies fn to the number's record and saves it atomically.
+	UpdatePhone(phone string, fn func(p *PhoneRecord) error) (PhoneRecord, error)
+}
+
+var phoneStore PhoneStore
+
+// LeaseStore hands out named, expiring locks so only one of several
+// instances sharing a store does a job at a time. The bolt store's leases
+// hold across processes using the same database file.
+type LeaseStore interface {
+	// AcquireLease takes or renews the lease for holder until ttl from now.
+	// It reports false while another holder's lease is unexpired.
+	AcquireLease(name string, holder string, ttl time.Duration) (bool, error)
+
+	// ReleaseLease gives the lease up if holder still has it.
+	ReleaseLease(name string, holder string) error
+}
+
+// normalizePhone converts a US-style number to E.164 (+15551234567), the
+// form Twilio uses on inbound messages. Other input, including email
+// addresses and URLs used as contacts, is returned trimmed.
+func normalizePhone(phone string) string {
+	if strings.ContainsAny(phone, "@/:") {
+		return strings.TrimSpace(phone)
+	}
+	var digits strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	var d string = digits.String()
+	switch {
+	case len(d) == 10:
+		return "+1" + d
+	case len(d) == 11 && d[0] == '1':
+		return "+" + d
+	case strings.HasPrefix(strings.TrimSpace(phone), "+") && len(d) >= 8:
+		return "+" + d
+	}
+	return strings.TrimSpace(phone)
+}
+
+// normalizeAlertPhones rewrites alerts saved before phone numbers were
+// normalized, so they're found by inbound SMS commands.
+func normalizeAlertPhones(store AlertStore) error {
+	alerts, err := store.List()
+	if err != nil {
+		return err
+	}
+	for _, a := range alerts {
+		if a.Phone == "" || normalizePhone(a.Phone) == a.Phone {
+			continue
+		}
+		_, err = store.Update(a.ID, func(a *platforms.Alert) error {
+			a.Phone = normalizePhone(a.Phone)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,910 @@
+package app
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"golf-teetimes/platforms"
+)
+
+// Bucket layout:
+//
+//	alerts      id → alert JSON
+//	idx_phone   owner \x00 id → nil (phone, or contact for phoneless alerts)
+//	idx_date    date \x00 id → nil
+//	outbox      key → OutboxMessage JSON (queued for delivery)
+//	outbox_dead key → OutboxMessage JSON (gave up after outboxMaxAttempts)
+//	outbox_sent key → RFC3339 time delivered
+//	history     alert id \x00 unix nanos \x00 key → NotificationRecord JSON
+//	snapshots   course key \x00 date → SlotSnapshot JSON
+//	openings    course key \x00 date → OpeningTally JSON
+//	slot_events unix nanos \x00 course key → SlotEvent JSON
+//	phones      E.164 number → PhoneRecord JSON
+//	meta        "secret:" name → server secret, "lease:" name → lease JSON,
+//	            "sms:" YYYY-MM-DD or YYYY-MM (UTC) → SMSUsage JSON
+var (
+	bucketAlerts   = []byte("alerts")
+	bucketIdxPhone = []byte("idx_phone")
+	bucketIdxDate  = []byte("idx_date")
+
+	bucketOutbox     = []byte("outbox")
+	bucketOutboxDead = []byte("outbox_dead")
+	bucketOutboxSent = []byte("outbox_sent")
+	bucketHistory    = []byte("history")
+
+	bucketSnapshots  = []byte("snapshots")
+	bucketSlotEvents = []byte("slot_events")
+	bucketOpenings   = []byte("openings")
+
+	bucketPhones = []byte("phones")
+	bucketMeta   = []byte("meta")
+)
+
+// boltAlertStore opens the database file only while transactions are running
+// and closes it once the last one finishes. bbolt locks the file while it is
+// open, so this lets the web server and alert-worker processes take turns
+// with one file; the worker lease in the meta bucket then decides which of
+// them checks alerts.
+type boltAlertStore struct {
+	path string
+
+	mu     sync.Mutex
+	db     *bolt.DB
+	users  int
+	closed bool
+}
+
+var errStoreClosed = errors.New("alert store is closed")
+
+func openBoltAlertStore(path string) (*boltAlertStore, error) {
+	var s *boltAlertStore = &boltAlertStore{path: path}
+	err := s.update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketAlerts, bucketIdxPhone, bucketIdxDate, bucketOutbox, bucketOutboxDead, bucketOutboxSent, bucketHistory, bucketSnapshots, bucketSlotEvents, bucketOpenings, bucketPhones, bucketMeta} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		// Databases from before the course index was dropped still have it
+		if tx.Bucket([]byte("idx_course")) != nil {
+			return tx.DeleteBucket([]byte("idx_course"))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// acquire opens the file if no transaction in this process has it open. It
+// waits up to the timeout for another process to let go of it.
+func (s *boltAlertStore) acquire() (*bolt.DB, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, errStoreClosed
+	}
+	if s.db == nil {
+		db, err := bolt.Open(s.path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+		if errors.Is(err, bolt.ErrTimeout) {
+			return nil, fmt.Errorf("%s stayed locked by another process", s.path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		s.db = db
+	}
+	s.users++
+	return s.db, nil
+}
+
+// release closes the file once no transaction in this process needs it.
+func (s *boltAlertStore) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users--
+	if s.users == 0 && s.db != nil {
+		if err := s.db.Close(); err != nil {
+			fmt.Println("  [ERROR] Closing alert store:", err)
+		}
+		s.db = nil
+	}
+}
+
+func (s *boltAlertStore) update(fn func(tx *bolt.Tx) error) error {
+	db, err := s.acquire()
+	if err != nil {
+		return err
+	}
+	defer s.release()
+	return db.Update(fn)
+}
+
+func (s *boltAlertStore) view(fn func(tx *bolt.Tx) error) error {
+	db, err := s.acquire()
+	if err != nil {
+		return err
+	}
+	defer s.release()
+	return db.View(fn)
+}
+
+func indexKey(parts ...string) []byte {
+	var b bytes.Buffer
+	for i, p := range parts {
+		if i > 0 {
+			b.WriteByte(0)
+		}
+		b.WriteString(p)
+	}
+	return b.Bytes()
+}
+
+func putAlertIndexes(tx *bolt.Tx, a platforms.Alert) error {
+	if err := tx.Bucket(bucketIdxPhone).Put(indexKey(alertOwner(a), a.ID), nil); err != nil {
+		return err
+	}
+	return tx.Bucket(bucketIdxDate).Put(indexKey(a.Date, a.ID), nil)
+}
+
+func deleteAlertIndexes(tx *bolt.Tx, a platforms.Alert) error {
+	if err := tx.Bucket(bucketIdxPhone).Delete(indexKey(alertOwner(a), a.ID)); err != nil {
+		return err
+	}
+	return tx.Bucket(bucketIdxDate).Delete(indexKey(a.Date, a.ID))
+}
+
+func getAlertTx(tx *bolt.Tx, id string) (platforms.Alert, error) {
+	var a platforms.Alert
+	var raw []byte = tx.Bucket(bucketAlerts).Get([]byte(id))
+	if raw == nil {
+		return a, ErrAlertNotFound
+	}
+	err := json.Unmarshal(raw, &a)
+	return a, err
+}
+
+func putAlertTx(tx *bolt.Tx, a platforms.Alert) error {
+	raw, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	if err = tx.Bucket(bucketAlerts).Put([]byte(a.ID), raw); err != nil {
+		return err
+	}
+	return putAlertIndexes(tx, a)
+}
+
+// alertsByPrefix loads every alert whose index key starts with prefix; the
+// alert ID is the last \x00-separated segment of the key.
+func alertsByPrefix(tx *bolt.Tx, bucket []byte, prefix []byte) ([]platforms.Alert, error) {
+	var alerts []platforms.Alert
+	c := tx.Bucket(bucket).Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		var id []byte = k[bytes.LastIndexByte(k, 0)+1:]
+		a, err := getAlertTx(tx, string(id))
+		if err == ErrAlertNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts, nil
+}
+
+func (s *boltAlertStore) List() ([]platforms.Alert, error) {
+	var alerts []platforms.Alert
+	err := s.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketAlerts).ForEach(func(k, v []byte) error {
+			var a platforms.Alert
+			if err := json.Unmarshal(v, &a); err != nil {
+				return err
+			}
+			alerts = append(alerts, a)
+			return nil
+		})
+	})
+	return alerts, err
+}
+
+func (s *boltAlertStore) Get(id string) (platforms.Alert, error) {
+	var a platforms.Alert
+	err := s.view(func(tx *bolt.Tx) error {
+		var err error
+		a, err = getAlertTx(tx, id)
+		return err
+	})
+	return a, err
+}
+
+func (s *boltAlertStore) ByPhone(phone string) ([]platforms.Alert, error) {
+	var alerts []platforms.Alert
+	err := s.view(func(tx *bolt.Tx) error {
+		var err error
+		alerts, err = alertsByPrefix(tx, bucketIdxPhone, append(indexKey(phone), 0))
+		return err
+	})
+	return alerts, err
+}
+
+func (s *boltAlertStore) Create(alert platforms.Alert, check func(existing []platforms.Alert) error) error {
+	return s.update(func(tx *bolt.Tx) error {
+		if check != nil {
+			existing, err := alertsByPrefix(tx, bucketIdxPhone, append(indexKey(alertOwner(alert)), 0))
+			if err != nil {
+				return err
+			}
+			if err = check(existing); err != nil {
+				return err
+			}
+		}
+		return putAlertTx(tx, alert)
+	})
+}
+
+func (s *boltAlertStore) Update(id string, fn func(a *platforms.Alert) error) (platforms.Alert, error) {
+	var updated platforms.Alert
+	err := s.update(func(tx *bolt.Tx) error {
+		old, err := getAlertTx(tx, id)
+		if err != nil {
+			return err
+		}
+		updated = old
+		if err = fn(&updated); err != nil {
+			return err
+		}
+		updated.ID = old.ID
+		if err = deleteAlertIndexes(tx, old); err != nil {
+			return err
+		}
+		return putAlertTx(tx, updated)
+	})
+	return updated, err
+}
+
+func (s *boltAlertStore) Delete(id string, check func(a platforms.Alert) error) error {
+	return s.update(func(tx *bolt.Tx) error {
+		a, err := getAlertTx(tx, id)
+		if err != nil {
+			return err
+		}
+		if check != nil {
+			if err = check(a); err != nil {
+				return err
+			}
+		}
+		if err = deleteAlertIndexes(tx, a); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketAlerts).Delete([]byte(id))
+	})
+}
+
+func (s *boltAlertStore) PruneBefore(date string) (int, error) {
+	var pruned int
+	err := s.update(func(tx *bolt.Tx) error {
+		// Collect first — bolt cursors must not be mutated while iterating
+		var stale []platforms.Alert
+		c := tx.Bucket(bucketIdxDate).Cursor()
+		for k, _ := c.First(); k != nil && string(k[:bytes.IndexByte(k, 0)]) < date; k, _ = c.Next() {
+			a, err := getAlertTx(tx, string(k[bytes.LastIndexByte(k, 0)+1:]))
+			if err == ErrAlertNotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			// Recurring alerts carry no Date; they expire with their EndDate
+			if a.Recurrence != nil && (a.Recurrence.EndDate == "" || a.Recurrence.EndDate >= date) {
+				continue
+			}
+			// Date-range alerts stay until their last day has passed
+			if a.DateTo != "" && a.DateTo >= date {
+				continue
+			}
+			stale = append(stale, a)
+		}
+		for _, a := range stale {
+			if err := deleteAlertIndexes(tx, a); err != nil {
+				return err
+			}
+			if err := tx.Bucket(bucketAlerts).Delete([]byte(a.ID)); err != nil {
+				return err
+			}
+		}
+		pruned = len(stale)
+		return nil
+	})
+	return pruned, err
+}
+
+func (s *boltAlertStore) Enqueue(msg OutboxMessage, update func(a *platforms.Alert) error) (bool, error) {
+	var queued bool
+	err := s.update(func(tx *bolt.Tx) error {
+		var key []byte = []byte(msg.Key)
+		for _, name := range [][]byte{bucketOutbox, bucketOutboxDead, bucketOutboxSent} {
+			if tx.Bucket(name).Get(key) != nil {
+				return nil
+			}
+		}
+
+		if update != nil {
+			old, err := getAlertTx(tx, msg.AlertID)
+			if err != nil {
+				return err
+			}
+			var updated platforms.Alert = old
+			if err = update(&updated); err != nil {
+				return err
+			}
+			updated.ID = old.ID
+			if err = deleteAlertIndexes(tx, old); err != nil {
+				return err
+			}
+			if err = putAlertTx(tx, updated); err != nil {
+				return err
+			}
+		}
+
+		queued = true
+		return putOutboxTx(tx, bucketOutbox, msg)
+	})
+	return queued, err
+}
+
+func putOutboxTx(tx *bolt.Tx, bucket []byte, msg OutboxMessage) error {
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(bucket).Put([]byte(msg.Key), raw)
+}
+
+func outboxMessages(tx *bolt.Tx, bucket []byte) ([]OutboxMessage, error) {
+	var msgs []OutboxMessage
+	err := tx.Bucket(bucket).ForEach(func(k, v []byte) error {
+		var msg OutboxMessage
+		if err := json.Unmarshal(v, &msg); err != nil {
+			return err
+		}
+		msgs = append(msgs, msg)
+		return nil
+	})
+	return msgs, err
+}
+
+func (s *boltAlertStore) Due(now time.Time) ([]OutboxMessage, error) {
+	var due []OutboxMessage
+	err := s.view(func(tx *bolt.Tx) error {
+		msgs, err := outboxMessages(tx, bucketOutbox)
+		if err != nil {
+			return err
+		}
+		for _, msg := range msgs {
+			next, err := time.Parse(time.RFC3339, msg.NextAttempt)
+			if err != nil || !next.After(now) {
+				due = append(due, msg)
+			}
+		}
+		return nil
+	})
+	return due, err
+}
+
+func (s *boltAlertStore) Complete(key string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(bucketOutbox).Delete([]byte(key)); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketOutboxSent).Put([]byte(key), []byte(time.Now().Format(time.RFC3339)))
+	})
+}
+
+func (s *boltAlertStore) CancelTo(channel string, to string) ([]OutboxMessage, error) {
+	var cancelled []OutboxMessage
+	err := s.update(func(tx *bolt.Tx) error {
+		msgs, err := outboxMessages(tx, bucketOutbox)
+		if err != nil {
+			return err
+		}
+		var stamp []byte = []byte(time.Now().Format(time.RFC3339))
+		for _, msg := range msgs {
+			if msg.Channel != channel || msg.To != to {
+				continue
+			}
+			if err := tx.Bucket(bucketOutbox).Delete([]byte(msg.Key)); err != nil {
+				return err
+			}
+			if err := tx.Bucket(bucketOutboxSent).Put([]byte(msg.Key), stamp); err != nil {
+				return err
+			}
+			cancelled = append(cancelled, msg)
+		}
+		return nil
+	})
+	return cancelled, err
+}
+
+func (s *boltAlertStore) Reschedule(msg OutboxMessage) error {
+	return s.update(func(tx *bolt.Tx) error {
+		// A message requeued or completed meanwhile isn't resurrected
+		if tx.Bucket(bucketOutbox).Get([]byte(msg.Key)) == nil {
+			return nil
+		}
+		return putOutboxTx(tx, bucketOutbox, msg)
+	})
+}
+
+func (s *boltAlertStore) DeadLetter(msg OutboxMessage) error {
+	return s.update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(bucketOutbox).Delete([]byte(msg.Key)); err != nil {
+			return err
+		}
+		return putOutboxTx(tx, bucketOutboxDead, msg)
+	})
+}
+
+func (s *boltAlertStore) Pending() ([]OutboxMessage, error) {
+	var msgs []OutboxMessage
+	err := s.view(func(tx *bolt.Tx) error {
+		var err error
+		msgs, err = outboxMessages(tx, bucketOutbox)
+		return err
+	})
+	return msgs, err
+}
+
+func (s *boltAlertStore) Dead() ([]OutboxMessage, error) {
+	var msgs []OutboxMessage
+	err := s.view(func(tx *bolt.Tx) error {
+		var err error
+		msgs, err = outboxMessages(tx, bucketOutboxDead)
+		return err
+	})
+	return msgs, err
+}
+
+var ErrOutboxMessageNotFound = errors.New("Outbox message not found")
+
+func (s *boltAlertStore) Requeue(key string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		var raw []byte = tx.Bucket(bucketOutboxDead).Get([]byte(key))
+		if raw == nil {
+			return ErrOutboxMessageNotFound
+		}
+		var msg OutboxMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return err
+		}
+		msg.Attempts = 0
+		msg.DeadAt = ""
+		msg.NextAttempt = time.Now().Format(time.RFC3339)
+		if err := tx.Bucket(bucketOutboxDead).Delete([]byte(key)); err != nil {
+			return err
+		}
+		return putOutboxTx(tx, bucketOutbox, msg)
+	})
+}
+
+func (s *boltAlertStore) PruneSent(before time.Time) (int, error) {
+	var pruned int
+	err := s.update(func(tx *bolt.Tx) error {
+		var stale [][]byte
+		err := tx.Bucket(bucketOutboxSent).ForEach(func(k, v []byte) error {
+			sent, err := time.Parse(time.RFC3339, string(v))
+			if err != nil || sent.Before(before) {
+				stale = append(stale, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range stale {
+			if err = tx.Bucket(bucketOutboxSent).Delete(k); err != nil {
+				return err
+			}
+		}
+		pruned = len(stale)
+		return nil
+	})
+	return pruned, err
+}
+
+func (s *boltAlertStore) RecordNotification(rec NotificationRecord) error {
+	raw, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	// Nanosecond keys keep records in order and distinct within a second
+	var seq string = fmt.Sprintf("%020d", time.Now().UnixNano())
+	return s.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketHistory).Put(indexKey(rec.AlertID, seq, rec.Key), raw)
+	})
+}
+
+func (s *boltAlertStore) History(alertID string) ([]NotificationRecord, error) {
+	var history []NotificationRecord
+	err := s.view(func(tx *bolt.Tx) error {
+		var prefix []byte = append(indexKey(alertID), 0)
+		c := tx.Bucket(bucketHistory).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var rec NotificationRecord
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return err
+			}
+			history = append(history, rec)
+		}
+		return nil
+	})
+	return history, err
+}
+
+func (s *boltAlertStore) PruneHistory(before time.Time) (int, error) {
+	var pruned int
+	err := s.update(func(tx *bolt.Tx) error {
+		var stale [][]byte
+		err := tx.Bucket(bucketHistory).ForEach(func(k, v []byte) error {
+			var rec NotificationRecord
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return err
+			}
+			at, err := time.Parse(time.RFC3339, rec.At)
+			if err != nil || at.Before(before) {
+				stale = append(stale, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range stale {
+			if err = tx.Bucket(bucketHistory).Delete(k); err != nil {
+				return err
+			}
+		}
+		pruned = len(stale)
+		return nil
+	})
+	return pruned, err
+}
+
+func (s *boltAlertStore) SwapSnapshot(courseKey string, date string, snap SlotSnapshot) (*SlotSnapshot, bool, error) {
+	var prev *SlotSnapshot
+	var saved bool
+	err := s.update(func(tx *bolt.Tx) error {
+		var key []byte = indexKey(courseKey, date)
+		if raw := tx.Bucket(bucketSnapshots).Get(key); raw != nil {
+			prev = &SlotSnapshot{}
+			if err := json.Unmarshal(raw, prev); err != nil {
+				return err
+			}
+			// RFC3339Nano drops trailing zeros, so compare as times
+			taken, err1 := time.Parse(time.RFC3339Nano, snap.Taken)
+			last, err2 := time.Parse(time.RFC3339Nano, prev.Taken)
+			if err1 == nil && err2 == nil && !taken.After(last) {
+				return nil
+			}
+		}
+		raw, err := json.Marshal(snap)
+		if err != nil {
+			return err
+		}
+		saved = true
+		if err = tallyOpeningsTx(tx, key, prev, snap); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketSnapshots).Put(key, raw)
+	})
+	return prev, saved, err
+}
+
+// tallyOpeningsTx adds the slots that gained openings since prev to the
+// sheet's tally. The first snapshot is a baseline, like in
+// recordSlotSnapshots, and only marks the sheet as watched.
+func tallyOpeningsTx(tx *bolt.Tx, key []byte, prev *SlotSnapshot, snap SlotSnapshot) error {
+	var tally OpeningTally = make(OpeningTally)
+	if raw := tx.Bucket(bucketOpenings).Get(key); raw != nil {
+		if err := json.Unmarshal(raw, &tally); err != nil {
+			return err
+		}
+	}
+	if prev != nil {
+		for slot, openings := range snap.Slots {
+			if openings > prev.Slots[slot] && openings > tally[slot] {
+				tally[slot] = openings
+			}
+		}
+	}
+	raw, err := json.Marshal(tally)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(bucketOpenings).Put(key, raw)
+}
+
+func (s *boltAlertStore) OpeningTallies(courseKey string, since string) (map[string]OpeningTally, error) {
+	var tallies map[string]OpeningTally = make(map[string]OpeningTally)
+	err := s.view(func(tx *bolt.Tx) error {
+		var prefix []byte = append(indexKey(courseKey), 0)
+		c := tx.Bucket(bucketOpenings).Cursor()
+		for k, v := c.Seek(indexKey(courseKey, since)); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var tally OpeningTally
+			if err := json.Unmarshal(v, &tally); err != nil {
+				return err
+			}
+			tallies[string(k[len(prefix):])] = tally
+		}
+		return nil
+	})
+	return tallies, err
+}
+
+func (s *boltAlertStore) PruneOpeningTallies(before string) (int, error) {
+	var pruned int
+	err := s.update(func(tx *bolt.Tx) error {
+		var stale [][]byte
+		err := tx.Bucket(bucketOpenings).ForEach(func(k, v []byte) error {
+			if string(k[bytes.LastIndexByte(k, 0)+1:]) < before {
+				stale = append(stale, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range stale {
+			if err = tx.Bucket(bucketOpenings).Delete(k); err != nil {
+				return err
+			}
+		}
+		pruned = len(stale)
+		return nil
+	})
+	return pruned, err
+}
+
+func (s *boltAlertStore) AddSlotEvents(events []SlotEvent) error {
+	return s.update(func(tx *bolt.Tx) error {
+		var now int64 = time.Now().UnixNano()
+		for i, ev := range events {
+			raw, err := json.Marshal(ev)
+			if err != nil {
+				return err
+			}
+			// The offset keeps events from one batch distinct and in order
+			var key []byte = indexKey(fmt.Sprintf("%020d", now+int64(i)), ev.CourseKey)
+			if err = tx.Bucket(bucketSlotEvents).Put(key, raw); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *boltAlertStore) SlotEvents(since time.Time) ([]SlotEvent, error) {
+	var events []SlotEvent
+	err := s.view(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketSlotEvents).Cursor()
+		for k, v := c.Seek([]byte(fmt.Sprintf("%020d", since.UnixNano()))); k != nil; k, v = c.Next() {
+			var ev SlotEvent
+			if err := json.Unmarshal(v, &ev); err != nil {
+				return err
+			}
+			events = append(events, ev)
+		}
+		return nil
+	})
+	return events, err
+}
+
+func (s *boltAlertStore) PruneSlots(before time.Time) (int, error) {
+	var pruned int
+	err := s.update(func(tx *bolt.Tx) error {
+		var stale [][]byte
+		var cutoff []byte = []byte(fmt.Sprintf("%020d", before.UnixNano()))
+		c := tx.Bucket(bucketSlotEvents).Cursor()
+		for k, _ := c.First(); k != nil && bytes.Compare(k, cutoff) < 0; k, _ = c.Next() {
+			stale = append(stale, append([]byte(nil), k...))
+		}
+		for _, k := range stale {
+			if err := tx.Bucket(bucketSlotEvents).Delete(k); err != nil {
+				return err
+			}
+		}
+		pruned = len(stale)
+
+		stale = nil
+		var day string = before.Format("2006-01-02")
+		err := tx.Bucket(bucketSnapshots).ForEach(func(k, v []byte) error {
+			if string(k[bytes.LastIndexByte(k, 0)+1:]) < day {
+				stale = append(stale, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range stale {
+			if err = tx.Bucket(bucketSnapshots).Delete(k); err != nil {
+				return err
+			}
+		}
+		pruned += len(stale)
+		return nil
+	})
+	return pruned, err
+}
+
+func (s *boltAlertStore) GetPhone(phone string) (PhoneRecord, error) {
+	var p PhoneRecord = PhoneRecord{Phone: phone}
+	err := s.view(func(tx *bolt.Tx) error {
+		var raw []byte = tx.Bucket(bucketPhones).Get([]byte(phone))
+		if raw == nil {
+			return nil
+		}
+		return json.Unmarshal(raw, &p)
+	})
+	return p, err
+}
+
+func (s *boltAlertStore) UpdatePhone(phone string, fn func(p *PhoneRecord) error) (PhoneRecord, error) {
+	var p PhoneRecord = PhoneRecord{Phone: phone}
+	err := s.update(func(tx *bolt.Tx) error {
+		var raw []byte = tx.Bucket(bucketPhones).Get([]byte(phone))
+		if raw != nil {
+			if err := json.Unmarshal(raw, &p); err != nil {
+				return err
+			}
+		}
+		if err := fn(&p); err != nil {
+			return err
+		}
+		p.Phone = phone
+		raw, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(bucketPhones).Put([]byte(phone), raw)
+	})
+	return p, err
+}
+
+// Secret returns the named random 32-byte secret, creating it on first use.
+func (s *boltAlertStore) Secret(name string) ([]byte, error) {
+	var secret []byte
+	err := s.update(func(tx *bolt.Tx) error {
+		var b *bolt.Bucket = tx.Bucket(bucketMeta)
+		if existing := b.Get([]byte("secret:" + name)); existing != nil {
+			secret = append([]byte(nil), existing...)
+			return nil
+		}
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return err
+		}
+		return b.Put([]byte("secret:"+name), secret)
+	})
+	return secret, err
+}
+
+type lease struct {
+	Holder  string `json:"holder"`
+	Expires string `json:"expires"`
+}
+
+func (s *boltAlertStore) AcquireLease(name string, holder string, ttl time.Duration) (bool, error) {
+	var acquired bool
+	err := s.update(func(tx *bolt.Tx) error {
+		var b *bolt.Bucket = tx.Bucket(bucketMeta)
+		var now time.Time = time.Now()
+		if raw := b.Get([]byte("lease:" + name)); raw != nil {
+			var current lease
+			if err := json.Unmarshal(raw, &current); err != nil {
+				return err
+			}
+			expires, err := time.Parse(time.RFC3339Nano, current.Expires)
+			if current.Holder != holder && err == nil && now.Before(expires) {
+				return nil
+			}
+		}
+		raw, err := json.Marshal(lease{Holder: holder, Expires: now.Add(ttl).Format(time.RFC3339Nano)})
+		if err != nil {
+			return err
+		}
+		acquired = true
+		return b.Put([]byte("lease:"+name), raw)
+	})
+	return acquired, err
+}
+
+func (s *boltAlertStore) ReleaseLease(name string, holder string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		var b *bolt.Bucket = tx.Bucket(bucketMeta)
+		var raw []byte = b.Get([]byte("lease:" + name))
+		if raw == nil {
+			return nil
+		}
+		var current lease
+		if err := json.Unmarshal(raw, &current); err != nil {
+			return err
+		}
+		if current.Holder != holder {
+			return nil
+		}
+		return b.Delete([]byte("lease:" + name))
+	})
+}
+
+func smsUsageKeys(now time.Time) ([]byte, []byte) {
+	var utc time.Time = now.UTC()
+	return []byte("sms:" + utc.Format("2006-01-02")), []byte("sms:" + utc.Format("2006-01"))
+}
+
+func getUsageTx(b *bolt.Bucket, key []byte) (SMSUsage, error) {
+	var u SMSUsage
+	if raw := b.Get(key); raw != nil {
+		if err := json.Unmarshal(raw, &u); err != nil {
+			return u, err
+		}
+	}
+	return u, nil
+}
+
+func (s *boltAlertStore) AddSMSUsage(now time.Time, segments int) error {
+	return s.update(func(tx *bolt.Tx) error {
+		var b *bolt.Bucket = tx.Bucket(bucketMeta)
+		dayKey, monthKey := smsUsageKeys(now)
+		for _, key := range [][]byte{dayKey, monthKey} {
+			u, err := getUsageTx(b, key)
+			if err != nil {
+				return err
+			}
+			u.Messages++
+			u.Segments += segments
+			raw, err := json.Marshal(u)
+			if err != nil {
+				return err
+			}
+			if err = b.Put(key, raw); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *boltAlertStore) SMSUsage(now time.Time) (SMSUsage, SMSUsage, error) {
+	var day, month SMSUsage
+	err := s.view(func(tx *bolt.Tx) error {
+		var b *bolt.Bucket = tx.Bucket(bucketMeta)
+		dayKey, monthKey := smsUsageKeys(now)
+		var err error
+		if day, err = getUsageTx(b, dayKey); err != nil {
+			return err
+		}
+		month, err = getUsageTx(b, monthKey)
+		return err
+	})
+	return day, month, err
+}
+
+func (s *boltAlertStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.db == nil {
+		return nil
+	}
+	var err error = s.db.Close()
+	s.db = nil
+	return err
+}
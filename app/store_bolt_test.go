@@ -0,0 +1,89 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T, path string) *boltAlertStore {
+	t.Helper()
+	store, err := openBoltAlertStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// useTestStore points every store at a fresh database for one test.
+func useTestStore(t *testing.T) *boltAlertStore {
+	t.Helper()
+	var store *boltAlertStore = openTestStore(t, filepath.Join(t.TempDir(), "alerts.db"))
+	alertStore = store
+	outbox = store
+	phoneStore = store
+	notificationLog = store
+	slotStore = store
+	usageStore = store
+	return store
+}
+
+func acquireLease(t *testing.T, store *boltAlertStore, holder string, ttl time.Duration) bool {
+	t.Helper()
+	ok, err := store.AcquireLease(workerLeaseName, holder, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ok
+}
+
+// Two stores on one file stand in for the web server and alert-worker
+// processes.
+func TestLeaseAcrossStores(t *testing.T) {
+	var path string = filepath.Join(t.TempDir(), "alerts.db")
+	var web *boltAlertStore = openTestStore(t, path)
+	var worker *boltAlertStore = openTestStore(t, path)
+
+	if !acquireLease(t, web, "web", time.Minute) {
+		t.Fatal("first holder didn't get the lease")
+	}
+	if acquireLease(t, worker, "worker", time.Minute) {
+		t.Fatal("second holder took an unexpired lease")
+	}
+	if !acquireLease(t, web, "web", time.Minute) {
+		t.Fatal("holder couldn't renew its lease")
+	}
+
+	if err := web.ReleaseLease(workerLeaseName, "worker"); err != nil {
+		t.Fatal(err)
+	}
+	if acquireLease(t, worker, "worker", time.Minute) {
+		t.Fatal("release by a non-holder freed the lease")
+	}
+
+	if err := web.ReleaseLease(workerLeaseName, "web"); err != nil {
+		t.Fatal(err)
+	}
+	if !acquireLease(t, worker, "worker", time.Minute) {
+		t.Fatal("lease not free after release")
+	}
+}
+
+func TestLeaseExpiryTakeover(t *testing.T) {
+	var path string = filepath.Join(t.TempDir(), "alerts.db")
+	var web *boltAlertStore = openTestStore(t, path)
+	var worker *boltAlertStore = openTestStore(t, path)
+
+	if !acquireLease(t, web, "web", 50*time.Millisecond) {
+		t.Fatal("first holder didn't get the lease")
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	if !acquireLease(t, worker, "worker", time.Minute) {
+		t.Fatal("expired lease wasn't taken over")
+	}
+	if acquireLease(t, web, "web", time.Minute) {
+		t.Fatal("old holder renewed a lease it lost")
+	}
+}
@@ -0,0 +1,252 @@
+package app
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	"golf-teetimes/platforms"
+)
+
+// ConsentTextVersion identifies the SMS consent wording shown next to the
+// alert form. Bump it whenever that text in templates/home.html changes.
+const ConsentTextVersion = "2025-06-01"
+
+const (
+	verificationCodeTTL     = 10 * time.Minute
+	verificationResendAfter = 1 * time.Minute
+	verificationMaxAttempts = 5
+	phoneVerificationTTL    = 180 * 24 * time.Hour
+)
+
+var (
+	ErrCodeExpired       = errors.New("That code has expired. Request a new one.")
+	ErrCodeInvalid       = errors.New("That code doesn't match. Please try again.")
+	ErrCodeTooMany       = errors.New("Too many wrong codes. Request a new one.")
+	ErrCodeResendTooSoon = errors.New("A code was just sent. Please wait a minute before requesting another.")
+)
+
+// consentFromRequest records who agreed to receive messages and to which
+// wording.
+func consentFromRequest(r *http.Request) *platforms.Consent {
+	return &platforms.Consent{
+		At:          time.Now().Format(time.RFC3339),
+		IP:          clientIP(r),
+		UserAgent:   r.UserAgent(),
+		TextVersion: ConsentTextVersion,
+	}
+}
+
+// phoneVerified reports whether the number confirmed a code recently enough.
+func phoneVerified(p PhoneRecord, now time.Time) bool {
+	if p.VerifiedAt == "" {
+		return false
+	}
+	verifiedAt, err := time.Parse(time.RFC3339, p.VerifiedAt)
+	if err != nil {
+		return false
+	}
+	return now.Sub(verifiedAt) < phoneVerificationTTL
+}
+
+func hashVerificationCode(phone string, code string) string {
+	sum := sha256.Sum256([]byte(phone + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
+
+// sendVerificationCode texts a fresh one-time code to the number for the
+// pending alert alertID, which the code can then turn on. A resend (alertID
+// empty) carries the alerts of the previous code over. Inside the resend
+// delay no new code goes out, but the alert is added to the current one.
+// Only the code's hash is stored.
+func sendVerificationCode(phone string, alertID string) error {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return err
+	}
+	var code string = fmt.Sprintf("%06d", n.Int64())
+	var now time.Time = time.Now()
+
+	var tooSoon bool
+	_, err = phoneStore.UpdatePhone(phone, func(p *PhoneRecord) error {
+		tooSoon = false
+		if alertID != "" && !containsString(p.CodeAlerts, alertID) {
+			p.CodeAlerts = append(p.CodeAlerts, alertID)
+		}
+		if sentAt, err := time.Parse(time.RFC3339, p.CodeSentAt); err == nil && now.Sub(sentAt) < verificationResendAfter {
+			tooSoon = true
+			return nil
+		}
+		// Checked before the code is saved, so a capped number isn't also
+		// held to the resend delay
+		if phoneCapReached(*p, now) {
+			return ErrSMSPhoneCapped
+		}
+		p.CodeHash = hashVerificationCode(phone, code)
+		p.CodeSentAt = now.Format(time.RFC3339)
+		p.CodeExpires = now.Add(verificationCodeTTL).Format(time.RFC3339)
+		p.CodeAttempts = 0
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if tooSoon {
+		return ErrCodeResendTooSoon
+	}
+
+	return sendSMS(phone, "Your FreeTeeTimeAlerts code is "+code+". It expires in 10 minutes.")
+}
+
+// confirmVerificationCode checks the code and, on success, marks the number
+// verified and turns on the alertIDs the code was sent for. Other pending
+// alerts on the number, which someone else may have created, stay off. It
+// returns how many alerts went live.
+func confirmVerificationCode(phone string, code string, alertIDs []string) (int, error) {
+	var now time.Time = time.Now()
+	var checkErr error
+	var codeAlerts []string
+	_, err := phoneStore.UpdatePhone(phone, func(p *PhoneRecord) error {
+		checkErr = nil
+		codeAlerts = p.CodeAlerts
+		expires, err := time.Parse(time.RFC3339, p.CodeExpires)
+		if p.CodeHash == "" || err != nil || now.After(expires) {
+			checkErr = ErrCodeExpired
+			return nil
+		}
+		if p.CodeAttempts >= verificationMaxAttempts {
+			checkErr = ErrCodeTooMany
+			return nil
+		}
+		if subtle.ConstantTimeCompare([]byte(p.CodeHash), []byte(hashVerificationCode(phone, code))) != 1 {
+			// Save the failed attempt
+			p.CodeAttempts++
+			checkErr = ErrCodeInvalid
+			return nil
+		}
+		p.CodeHash = ""
+		p.CodeExpires = ""
+		p.CodeAttempts = 0
+		p.CodeAlerts = nil
+		p.VerifiedAt = now.Format(time.RFC3339)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if checkErr != nil {
+		return 0, checkErr
+	}
+
+	var activated int
+	for _, id := range alertIDs {
+		if !containsString(codeAlerts, id) {
+			continue
+		}
+		var turnedOn bool
+		_, err = alertStore.Update(id, func(a *platforms.Alert) error {
+			turnedOn = false
+			if a.Phone != phone || !a.Pending {
+				return nil
+			}
+			a.Pending = false
+			a.Active = true
+			turnedOn = true
+			return nil
+		})
+		if err != nil && err != ErrAlertNotFound {
+			return activated, err
+		}
+		if turnedOn {
+			activated++
+		}
+	}
+	return activated, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func handleSendVerificationCode(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	if alertRateLimited(r) {
+		w.WriteHeader(429)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Too many requests. Please try again in a minute."})
+		return
+	}
+
+	var req struct {
+		Phone string `json:"phone"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Phone == "" {
+		w.WriteHeader(400)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Phone number required."})
+		return
+	}
+
+	var err error = sendVerificationCode(normalizePhone(req.Phone), "")
+	if err != nil {
+		w.WriteHeader(400)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"status": "sent"})
+}
+
+func handleVerifyPhone(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	if alertRateLimited(r) {
+		w.WriteHeader(429)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Too many requests. Please try again in a minute."})
+		return
+	}
+
+	var req struct {
+		Phone    string   `json:"phone"`
+		Code     string   `json:"code"`
+		AlertIDs []string `json:"alertIds"` // the pending alerts this browser created
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Phone == "" || req.Code == "" {
+		w.WriteHeader(400)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Phone number and code required."})
+		return
+	}
+
+	var phone string = normalizePhone(req.Phone)
+	activated, err := confirmVerificationCode(phone, req.Code, req.AlertIDs)
+	if err != nil {
+		w.WriteHeader(400)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	// A confirmed code doubles as sign-in for managing the number's alerts
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "verified",
+		"activated": activated,
+		"token":     signManageToken(phone, manageSessionTTL),
+	})
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"golf-teetimes/platforms"
+)
+
+func TestConfirmVerificationCodeTurnsOnOnlyNamedAlerts(t *testing.T) {
+	useTestStore(t)
+	var phone string = "+13035550100"
+	var date string = time.Now().AddDate(0, 0, 2).Format("2006-01-02")
+
+	for _, id := range []string{"mine", "someone-elses", "unbound"} {
+		var a platforms.Alert = platforms.Alert{ID: id, Phone: phone, Metro: "denver", Date: date, StartTime: "7:00 AM", EndTime: "11:00 AM", Pending: true}
+		if err := alertStore.Create(a, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := phoneStore.UpdatePhone(phone, func(p *PhoneRecord) error {
+		p.CodeHash = hashVerificationCode(phone, "123456")
+		p.CodeExpires = time.Now().Add(verificationCodeTTL).Format(time.RFC3339)
+		p.CodeAlerts = []string{"mine", "someone-elses"}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	activated, err := confirmVerificationCode(phone, "123456", []string{"mine", "unbound"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if activated != 1 {
+		t.Errorf("activated = %d, want 1", activated)
+	}
+	for id, want := range map[string]bool{"mine": true, "someone-elses": false, "unbound": false} {
+		a, err := alertStore.Get(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.Active != want || a.Pending == want {
+			t.Errorf("%s: active = %v, pending = %v; want active %v", id, a.Active, a.Pending, want)
+		}
+	}
+}
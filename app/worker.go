@@ -0,0 +1,160 @@
+package app
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// The alert worker holds this lease while it checks alerts and delivers the
+// outbox, so instances sharing a store never both send.
+const (
+	workerLeaseName  = "alert-worker"
+	workerLeaseTTL   = 45 * time.Second
+	workerLeaseRenew = 15 * time.Second
+)
+
+// alertWorker runs the alert checker and the outbox delivery loop while it is
+// the leader. Instances that aren't keep trying to take the lease over.
+type alertWorker struct {
+	leases LeaseStore
+	holder string
+
+	leading atomic.Bool
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// RunWorker runs the alert worker without the web server until SIGINT or
+// SIGTERM.
+func RunWorker() {
+	store, err := openStores()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer store.Close()
+
+	var worker *alertWorker = newAlertWorker(store)
+	worker.Start()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	<-sigCh
+	fmt.Println("Shutting down gracefully...")
+	worker.Stop()
+}
+
+func newAlertWorker(leases LeaseStore) *alertWorker {
+	var suffix []byte = make([]byte, 4)
+	rand.Read(suffix)
+	host, _ := os.Hostname()
+	return &alertWorker{
+		leases: leases,
+		holder: fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(suffix)),
+		stop:   make(chan struct{}),
+	}
+}
+
+func (w *alertWorker) Start() {
+	fmt.Println("Alert worker started as", w.holder)
+	w.wg.Add(3)
+	go w.leaseLoop()
+	go w.checkLoop()
+	go w.outboxLoop()
+}
+
+// Stop waits for the current check or delivery to finish, then gives up the
+// lease so another instance can take over right away.
+func (w *alertWorker) Stop() {
+	close(w.stop)
+	w.wg.Wait()
+	if err := w.leases.ReleaseLease(workerLeaseName, w.holder); err != nil {
+		fmt.Println("  [ERROR] Releasing worker lease:", err)
+	}
+	fmt.Println("Alert worker stopped")
+}
+
+// wait sleeps for d, returning early (true) when woken and false once the
+// worker is stopping.
+func (w *alertWorker) wait(d time.Duration, wake <-chan struct{}) bool {
+	select {
+	case <-w.stop:
+		return false
+	case <-wake:
+		return true
+	case <-time.After(d):
+		return true
+	}
+}
+
+func (w *alertWorker) leaseLoop() {
+	defer w.wg.Done()
+	for {
+		leader, err := w.leases.AcquireLease(workerLeaseName, w.holder, workerLeaseTTL)
+		if err != nil {
+			fmt.Println("  [ERROR] Renewing worker lease:", err)
+			leader = false
+		}
+		if leader != w.leading.Load() {
+			if leader {
+				fmt.Println("  Alert worker is now the leader")
+			} else {
+				fmt.Println("  Alert worker lost the lease — standing by")
+			}
+			w.leading.Store(leader)
+		}
+		if !w.wait(workerLeaseRenew, nil) {
+			w.leading.Store(false)
+			return
+		}
+	}
+}
+
+func (w *alertWorker) checkLoop() {
+	defer w.wg.Done()
+
+	// Last fetch per group, reused until the group is due again
+	var lastFetch map[alertGroup]groupFetch = make(map[alertGroup]groupFetch)
+
+	for {
+		// Standbys look again soon after each lease attempt
+		var sleep time.Duration = workerLeaseRenew
+		if w.leading.Load() {
+			sleep = checkAlerts(lastFetch)
+		}
+		if !w.wait(sleep, nil) {
+			return
+		}
+	}
+}
+
+func (w *alertWorker) outboxLoop() {
+	defer w.wg.Done()
+
+	var lastPrune time.Time
+	for {
+		var now time.Time = time.Now()
+		if w.leading.Load() {
+			if now.Sub(lastPrune) > 24*time.Hour {
+				if _, err := outbox.PruneSent(now.Add(-outboxSentTTL)); err != nil {
+					fmt.Println("  [ERROR] Pruning outbox:", err)
+				}
+				if _, err := notificationLog.PruneHistory(now.Add(-historyTTL)); err != nil {
+					fmt.Println("  [ERROR] Pruning notification history:", err)
+				}
+				lastPrune = now
+			}
+			deliverOutbox(now)
+		}
+		if !w.wait(outboxPollInterval, outboxWake) {
+			return
+		}
+	}
+}
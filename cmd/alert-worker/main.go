@@ -0,0 +1,13 @@
+package main
+
+import "golf-teetimes/app"
+
+// Alert Worker
+//
+// Checks alerts and delivers the outbox without serving the web app. Run it
+// from the repo root, like the server, so it opens the same data/alerts.db.
+// Start the web server with ALERT_WORKER=off to leave checking to this
+// process; if both run a worker, the lease lets only one of them check.
+func main() {
+	app.RunWorker()
+}
@@ -11,6 +11,8 @@ import (
 	"os"
 	"strings"
 	"time"
+
+	"golf-teetimes/platforms"
 )
 
 // CPS Golf Discovery Tool
@@ -23,6 +25,8 @@ import (
 //   2. If valid JSON → extract apiKey, siteName
 //   3. Call /OnlineCourses with headers → get websiteId, courseIds, timezone
 //   4. Call /TeeTimes on 3 dates → confirm vs listed_only
+//   5. If no modern site matched, probe the legacy V3 interface at
+//      e.cps.golf/{slug}V3/ and emit a cpsv3.json config for it
 //
 // Usage: go run cmd/discover-cpsgolf/main.go <state> -f <file>
 
@@ -77,6 +81,19 @@ type CPSGetAllOptionsResponse struct {
 	} `json:"courseOptions"`
 }
 
+// CPSV3Config mirrors platforms.CPSV3CourseConfig so V3 hits can be pasted
+// straight into platforms/data/cpsv3.json.
+type CPSV3Config struct {
+	Key        string            `json:"key"`
+	Metro      string            `json:"metro"`
+	BaseURL    string            `json:"baseUrl"`
+	CourseIDs  string            `json:"courseIds"`
+	BookingURL string            `json:"bookingUrl"`
+	Names      map[string]string `json:"names"`
+	City       string            `json:"city"`
+	State      string            `json:"state"`
+}
+
 // --- Result types ---
 
 type Result struct {
@@ -91,6 +108,7 @@ type Result struct {
 	TeeTimes     []int       `json:"teeTimes,omitempty"`
 	HasPrice     bool        `json:"hasPrice,omitempty"`
 	SlugsTried   []string    `json:"slugsTried,omitempty"`
+	V3Config     *CPSV3Config `json:"v3Config,omitempty"`
 }
 
 // --- Helpers ---
@@ -504,6 +522,59 @@ func fetchTeeTimeCount(client *http.Client, config *CPSConfig, courses []CPSCour
 	return len(slots), hasPrice, nil
 }
 
+// --- Legacy V3 probing ---
+
+// fetchV3Page loads and parses the server-rendered V3 tee sheet for a date.
+// An empty courseID lets the site pick its default course.
+func fetchV3Page(client *http.Client, baseURL, date, courseID string) (platforms.CPSV3Page, error) {
+	var page platforms.CPSV3Page
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return page, err
+	}
+	params := url.Values{
+		"Date":   {t.Format("1/2/2006")},
+		"Time":   {"AnyTime"},
+		"Player": {"99"},
+		"Hole":   {"Any"},
+	}
+	if courseID != "" {
+		params.Set("CourseId", courseID)
+	}
+	req, err := http.NewRequest("GET", baseURL+"/Home/nIndex?"+params.Encode(), nil)
+	if err != nil {
+		return page, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36")
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return page, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return page, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return platforms.ParseCPSV3Page(resp.Body)
+}
+
+// probeV3 checks whether e.cps.golf/{slug}V3 is a live legacy site and
+// returns its courses. The page must parse as a V3 tee sheet (search form
+// and course picker), not a generic IIS error page.
+func probeV3(client *http.Client, slug, date string) (string, []platforms.CPSV3Course, error) {
+	baseURL := fmt.Sprintf("https://e.cps.golf/%sV3", slug)
+	page, err := fetchV3Page(client, baseURL, date, "")
+	if err != nil {
+		return "", nil, err
+	}
+	if len(page.Courses) == 0 {
+		return "", nil, fmt.Errorf("no courses in the V3 picker")
+	}
+	return baseURL, page.Courses, nil
+}
+
 // --- State validation via timezone ---
 
 // timezoneStates maps common timezones to states they belong in.
@@ -641,6 +712,84 @@ func main() {
 		}
 
 		if config == nil {
+			// Fall back to the legacy V3 interface before calling it a miss
+			var v3Base string
+			var v3Courses []platforms.CPSV3Course
+			for _, s := range live {
+				if strings.Contains(s.slug, "-") {
+					continue
+				}
+				base, courses, err := probeV3(client, s.slug, dates[0])
+				if err != nil {
+					time.Sleep(100 * time.Millisecond)
+					continue
+				}
+				v3Base = base
+				v3Courses = courses
+				matchedSlug = s.slug
+				matchedSource = s.source
+				break
+			}
+
+			if v3Base != "" {
+				log("  %s (%s): V3 HIT — %s", matchedSlug, matchedSource, v3Base)
+				var datesChecked []string
+				var teeTimes []int
+				totalTimes := 0
+				// One course keeps the input's name; several keep their own
+				names := map[string]string{}
+				var courseIDs []string
+				for _, vc := range v3Courses {
+					courseIDs = append(courseIDs, vc.ID)
+					names[vc.Name] = vc.Name
+					if len(v3Courses) == 1 {
+						names[vc.Name] = c.Name
+					}
+				}
+				for _, date := range dates {
+					count := 0
+					for _, vc := range v3Courses {
+						page, err := fetchV3Page(client, v3Base, date, vc.ID)
+						if err != nil {
+							log("    %s course %s: error — %v", date, vc.ID, err)
+							continue
+						}
+						count += len(page.Rows)
+					}
+					log("    %s: %d tee times", date, count)
+					datesChecked = append(datesChecked, date)
+					teeTimes = append(teeTimes, count)
+					totalTimes += count
+					time.Sleep(300 * time.Millisecond)
+				}
+
+				status := "listed_only"
+				if totalTimes > 0 {
+					status = "confirmed"
+					confirmed++
+					log("  ✅ CONFIRMED (V3) — %d total tee times", totalTimes)
+				} else {
+					listedOnly++
+					log("  ⚠️  LISTED ONLY (V3) — 0 tee times")
+				}
+				results = append(results, Result{
+					Input: c.Name, City: c.City, Status: status,
+					Slug: matchedSlug, SlugSource: matchedSource,
+					DatesChecked: datesChecked, TeeTimes: teeTimes,
+					V3Config: &CPSV3Config{
+						Key:        strings.Join(strings.Fields(coreName(c.Name)), "-"),
+						BaseURL:    v3Base,
+						CourseIDs:  strings.Join(courseIDs, ","),
+						BookingURL: v3Base + "/Home/nIndex",
+						Names:      names,
+						City:       c.City,
+						State:      state,
+					},
+				})
+				log("")
+				continue
+			}
+
 			missed++
 			log("  MISS — no slug matched")
 			results = append(results, Result{
@@ -768,6 +917,11 @@ func main() {
 				for j, cc := range r.Courses {
 					courseDescs[j] = fmt.Sprintf("%s (%dh)", cc.CourseName, cc.Holes)
 				}
+				if r.V3Config != nil {
+					log("  %-45s slug:%-30s [%s] %s  [%d times]  (V3)",
+						r.Input, r.Slug, r.SlugSource, r.V3Config.BaseURL, sum(r.TeeTimes))
+					continue
+				}
 				log("  %-45s slug:%-30s [%s] %s.cps.golf  [%d times]  courses=%v",
 					r.Input, r.Slug, r.SlugSource, r.Slug, sum(r.TeeTimes), courseDescs)
 			}
@@ -775,6 +929,19 @@ func main() {
 		log("")
 	}
 
+	var v3Configs []*CPSV3Config
+	for _, r := range results {
+		if r.Status == "confirmed" && r.V3Config != nil {
+			v3Configs = append(v3Configs, r.V3Config)
+		}
+	}
+	if len(v3Configs) > 0 {
+		log("=== V3 CONFIGS (add metro, then paste into platforms/data/cpsv3.json) ===")
+		v3JSON, _ := json.MarshalIndent(v3Configs, "", "  ")
+		fmt.Println(string(v3JSON))
+		log("")
+	}
+
 	if listedOnly > 0 {
 		log("=== LISTED ONLY ===")
 		for _, r := range results {
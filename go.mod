@@ -1,3 +1,10 @@
 module golf-teetimes
 
 go 1.25.6
+
+require (
+	go.etcd.io/bbolt v1.4.3
+	golang.org/x/net v0.57.0
+)
+
+require golang.org/x/sys v0.47.0 // indirect
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 type ChronogolfCourseConfig struct {
@@ -25,10 +26,35 @@ type ChronogolfCourseConfig struct {
 var ChronogolfCourses = map[string]ChronogolfCourseConfig{}
 
 type ChronogolfResponse struct {
-	Status   string           `json:"status"`
-	TeeTimes []ChronogolfSlot `json:"teetimes"`
+	Status     string           `json:"status"`
+	TeeTimes   []ChronogolfSlot `json:"teetimes"`
+	Page       int              `json:"page"`
+	TotalPages int              `json:"total_pages"`
+	HasMore    *bool            `json:"has_more"`
 }
 
+// lastPage reports whether the response says it is the last page, and whether
+// it carried a paging signal at all.
+func (r ChronogolfResponse) lastPage(page int) (bool, bool) {
+	if r.HasMore != nil {
+		return !*r.HasMore, true
+	}
+	if r.TotalPages > 0 {
+		if r.Page > 0 {
+			page = r.Page
+		}
+		return page >= r.TotalPages, true
+	}
+	return false, false
+}
+
+// chronogolfMaxPages guards against paging that never ends.
+const chronogolfMaxPages = 20
+
+// chronogolfClubWorkers bounds the club tee sheet requests in flight for one
+// config.
+const chronogolfClubWorkers = 4
+
 type ChronogolfSlot struct {
 	StartTime     string              `json:"start_time"`
 	MaxPlayerSize int                 `json:"max_player_size"`
@@ -46,6 +72,7 @@ type ChronogolfPrice struct {
 }
 
 type ChronogolfClubSlot struct {
+	CourseID      int                      `json:"course_id"`
 	StartTime     string                   `json:"start_time"`
 	OutOfCapacity bool                     `json:"out_of_capacity"`
 	GreenFees     []ChronogolfClubGreenFee `json:"green_fees"`
@@ -73,12 +100,52 @@ func toFloat(n json.Number) float64 {
 	return f
 }
 
-func fetchChronogolfPage(url string) ([]ChronogolfSlot, bool, error) {
+// formatChronogolfTime converts a 24h "HH:MM" start time to "H:MM AM/PM".
+func formatChronogolfTime(startTime string) string {
+	var hours int
+	var mins int
+	fmt.Sscanf(startTime, "%d:%d", &hours, &mins)
+
+	var period string = "AM"
+	if hours >= 12 {
+		period = "PM"
+	}
+	if hours > 12 {
+		hours = hours - 12
+	}
+	if hours == 0 {
+		hours = 12
+	}
+	return fmt.Sprintf("%d:%02d %s", hours, mins, period)
+}
+
+// chronogolfDisplayName maps an API course name (or numeric club course ID)
+// to its display name. A single-course config always resolves to its only name.
+func chronogolfDisplayName(config ChronogolfCourseConfig, apiName string) string {
+	if dn, ok := config.Names[apiName]; ok {
+		return dn
+	}
+	trimmed := strings.TrimSpace(apiName)
+	for k, v := range config.Names {
+		if strings.EqualFold(strings.TrimSpace(k), trimmed) {
+			return v
+		}
+	}
+	if len(config.Names) == 1 {
+		for _, v := range config.Names {
+			return v
+		}
+	}
+	return ""
+}
+
+// fetchChronogolfPage returns one marketplace page.
+func fetchChronogolfPage(url string) (ChronogolfResponse, error) {
 	var req *http.Request
 	var err error
 	req, err = http.NewRequest("GET", url, nil)
 	if err != nil {
-		return nil, false, err
+		return ChronogolfResponse{}, err
 	}
 
 	req.Header.Set("Accept", "application/json")
@@ -87,27 +154,23 @@ func fetchChronogolfPage(url string) ([]ChronogolfSlot, bool, error) {
 	var resp *http.Response
 	resp, err = client.Do(req)
 	if err != nil {
-		return nil, false, err
+		return ChronogolfResponse{}, err
 	}
 	defer resp.Body.Close()
 
 	var body []byte
 	body, err = io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, false, err
+		return ChronogolfResponse{}, err
 	}
 
 	var data ChronogolfResponse
 	err = json.Unmarshal(body, &data)
 	if err != nil {
-		return nil, false, err
-	}
-
-	if len(data.TeeTimes) == 0 {
-		return nil, true, nil
+		return ChronogolfResponse{}, err
 	}
 
-	return data.TeeTimes, len(data.TeeTimes) < 24, nil
+	return data, nil
 }
 
 func FetchChronogolf(config ChronogolfCourseConfig, date string) ([]DisplayTeeTime, error) {
@@ -115,70 +178,60 @@ func FetchChronogolf(config ChronogolfCourseConfig, date string) ([]DisplayTeeTi
 		return FetchChronogolfClub(config, date)
 	}
 	var allSlots []ChronogolfSlot
-	var page int = 1
 
-	for {
+	// Page by the response's has_more / total_pages. Without either, a page
+	// shorter than the first is the last. Either way, an empty page or one
+	// that repeats the page before (paging ignored) ends the loop.
+	var pageSize int
+	var prevFirst string
+	for page := 1; page <= chronogolfMaxPages; page++ {
 		var url string = fmt.Sprintf(
 			"https://www.chronogolf.com/marketplace/v2/teetimes?start_date=%s&course_ids=%s&holes=9,18&page=%d",
 			date, config.CourseIDs, page,
 		)
 
-		slots, done, err := fetchChronogolfPage(url)
+		data, err := fetchChronogolfPage(url)
 		if err != nil {
 			return nil, err
 		}
+		var slots []ChronogolfSlot = data.TeeTimes
+		if len(slots) == 0 {
+			break
+		}
+		var first string = slots[0].StartTime + "|" + slots[0].Course.Name
+		if page > 1 && first == prevFirst {
+			break
+		}
+		prevFirst = first
 
 		allSlots = append(allSlots, slots...)
 
-		if done {
+		if page == 1 {
+			pageSize = len(slots)
+		}
+		if last, signaled := data.lastPage(page); signaled {
+			if last {
+				break
+			}
+		} else if len(slots) < pageSize {
 			break
 		}
-
-		page++
 	}
 
 	var results []DisplayTeeTime
 	for _, slot := range allSlots {
-		var hours int
-		var mins int
-		fmt.Sscanf(slot.StartTime, "%d:%d", &hours, &mins)
-
-		var period string = "AM"
-		if hours >= 12 {
-			period = "PM"
-		}
-		if hours > 12 {
-			hours = hours - 12
-		}
-		if hours == 0 {
-			hours = 12
-		}
-		var timeStr string = fmt.Sprintf("%d:%02d %s", hours, mins, period)
-
 		var courseName string = slot.Course.Name
-		var displayName string = config.Names[courseName]
-		if displayName == "" {
-			trimmed := strings.TrimSpace(courseName)
-			for k, v := range config.Names {
-				if strings.EqualFold(strings.TrimSpace(k), trimmed) {
-					displayName = v
-					break
-				}
-			}
-		}
-		if displayName != "" {
+		if displayName := chronogolfDisplayName(config, courseName); displayName != "" {
 			courseName = displayName
 		}
 
-		var holesStr string = formatHoles(slot.Course.BookableHoles)
-
 		results = append(results, DisplayTeeTime{
-			Time:       timeStr,
+			Time:       formatChronogolfTime(slot.StartTime),
 			Course:     courseName,
 			City:       config.City,
 			State:      config.State,
 			Openings:   slot.MaxPlayerSize,
-			Holes:      holesStr,
+			Holes:      formatHoles(slot.Course.BookableHoles),
 			Price:      toFloat(slot.DefaultPrice.GreenFee),
 			BookingURL: config.BookingURL + "?date=" + date + "&step=teetimes",
 		})
@@ -187,10 +240,16 @@ func FetchChronogolf(config ChronogolfCourseConfig, date string) ([]DisplayTeeTi
 	return results, nil
 }
 
-func FetchChronogolfClub(config ChronogolfCourseConfig, date string) ([]DisplayTeeTime, error) {
+// fetchChronogolfClubSlots queries the club tee sheet for one course, hole
+// count and party size. The API expects one affiliation type per golfer.
+func fetchChronogolfClubSlots(config ChronogolfCourseConfig, courseID string, date string, holes int, players int) ([]ChronogolfClubSlot, error) {
+	var affiliations string
+	for i := 0; i < players; i++ {
+		affiliations += "&affiliation_type_ids%5B%5D=" + config.AffiliationTypeID
+	}
 	var url string = fmt.Sprintf(
-		"https://www.chronogolf.com/marketplace/clubs/%s/teetimes?date=%s&course_id=%s&affiliation_type_ids%%5B%%5D=%s&nb_holes=18",
-		config.ClubID, date, config.NumericCourseID, config.AffiliationTypeID,
+		"https://www.chronogolf.com/marketplace/clubs/%s/teetimes?date=%s&course_id=%s%s&nb_holes=%d",
+		config.ClubID, date, courseID, affiliations, holes,
 	)
 
 	var req *http.Request
@@ -221,34 +280,107 @@ func FetchChronogolfClub(config ChronogolfCourseConfig, date string) ([]DisplayT
 	if err != nil {
 		return nil, err
 	}
+	return slots, nil
+}
 
-	var displayName string
-	for _, v := range config.Names {
-		displayName = v
-		break
+// FetchChronogolfClub queries every configured club course for 9 and 18 holes
+// at party sizes 1–4, at most chronogolfClubWorkers at a time. Openings is
+// the largest party size the slot still fits.
+func FetchChronogolfClub(config ChronogolfCourseConfig, date string) ([]DisplayTeeTime, error) {
+	var courseIDs []string
+	for _, id := range strings.Split(config.NumericCourseID, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			courseIDs = append(courseIDs, id)
+		}
 	}
 
-	var results []DisplayTeeTime
-	for _, slot := range slots {
-		if slot.OutOfCapacity {
-			continue
-		}
+	type clubQuery struct {
+		courseID string
+		holes    int
+		players  int
+	}
+	type clubResult struct {
+		query clubQuery
+		slots []ChronogolfClubSlot
+		err   error
+	}
 
-		var hours int
-		var mins int
-		fmt.Sscanf(slot.StartTime, "%d:%d", &hours, &mins)
+	var queries []clubQuery
+	for _, id := range courseIDs {
+		for _, holes := range []int{9, 18} {
+			for players := 1; players <= 4; players++ {
+				queries = append(queries, clubQuery{courseID: id, holes: holes, players: players})
+			}
+		}
+	}
 
-		var period string = "AM"
-		if hours >= 12 {
-			period = "PM"
+	var ch chan clubResult = make(chan clubResult, len(queries))
+	var wg sync.WaitGroup
+	var sem chan struct{} = make(chan struct{}, chronogolfClubWorkers)
+	for _, q := range queries {
+		wg.Add(1)
+		go func(q clubQuery) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			var slots []ChronogolfClubSlot
+			var err error
+			slots, err = fetchChronogolfClubSlots(config, q.courseID, date, q.holes, q.players)
+			ch <- clubResult{query: q, slots: slots, err: err}
+		}(q)
+	}
+	go func() {
+		wg.Wait()
+		close(ch)
+	}()
+
+	// Key each slot by course, hole count and start time
+	type slotKey struct {
+		courseID string
+		holes    int
+		start    string
+	}
+	var base map[slotKey]ChronogolfClubSlot = make(map[slotKey]ChronogolfClubSlot)
+	var openings map[slotKey]int = make(map[slotKey]int)
+	// Slots only come from the single-golfer query, so if one of those fails
+	// the course would look empty; the larger party sizes only refine
+	// openings and may fail without losing slots.
+	var baseErr error
+	for res := range ch {
+		if res.err != nil {
+			if res.query.players == 1 && baseErr == nil {
+				baseErr = res.err
+			}
+			continue
 		}
-		if hours > 12 {
-			hours = hours - 12
+		for _, slot := range res.slots {
+			if slot.OutOfCapacity {
+				continue
+			}
+			var courseID string = res.query.courseID
+			if slot.CourseID != 0 {
+				courseID = strconv.Itoa(slot.CourseID)
+			}
+			var key slotKey = slotKey{courseID: courseID, holes: res.query.holes, start: slot.StartTime}
+			if res.query.players == 1 {
+				base[key] = slot
+			}
+			if res.query.players > openings[key] {
+				openings[key] = res.query.players
+			}
 		}
-		if hours == 0 {
-			hours = 12
+	}
+
+	if baseErr != nil {
+		return nil, baseErr
+	}
+
+	var results []DisplayTeeTime
+	for key, slot := range base {
+		var displayName string = chronogolfDisplayName(config, key.courseID)
+		if displayName == "" {
+			continue
 		}
-		var timeStr string = fmt.Sprintf("%d:%02d %s", hours, mins, period)
 
 		var price float64
 		if len(slot.GreenFees) > 0 {
@@ -256,12 +388,12 @@ func FetchChronogolfClub(config ChronogolfCourseConfig, date string) ([]DisplayT
 		}
 
 		results = append(results, DisplayTeeTime{
-			Time:       timeStr,
+			Time:       formatChronogolfTime(slot.StartTime),
 			Course:     displayName,
 			City:       config.City,
 			State:      config.State,
-			Openings:   len(slot.GreenFees),
-			Holes:      "18",
+			Openings:   openings[key],
+			Holes:      strconv.Itoa(key.holes),
 			Price:      price,
 			BookingURL: config.BookingURL + "?date=" + date + "&step=teetimes",
 		})
@@ -0,0 +1,37 @@
+package platforms
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestChronogolfLastPage(t *testing.T) {
+	var cases []struct {
+		body     string
+		page     int
+		last     bool
+		signaled bool
+	} = []struct {
+		body     string
+		page     int
+		last     bool
+		signaled bool
+	}{
+		{`{"teetimes":[],"has_more":true}`, 1, false, true},
+		{`{"teetimes":[],"has_more":false,"total_pages":5}`, 1, true, true},
+		{`{"teetimes":[],"page":2,"total_pages":3}`, 2, false, true},
+		{`{"teetimes":[],"page":3,"total_pages":3}`, 1, true, true},
+		{`{"teetimes":[],"total_pages":2}`, 2, true, true},
+		{`{"teetimes":[]}`, 4, false, false},
+	}
+	for _, c := range cases {
+		var resp ChronogolfResponse
+		if err := json.Unmarshal([]byte(c.body), &resp); err != nil {
+			t.Fatal(err)
+		}
+		last, signaled := resp.lastPage(c.page)
+		if last != c.last || signaled != c.signaled {
+			t.Errorf("%s page %d: got (%v, %v), want (%v, %v)", c.body, c.page, last, signaled, c.last, c.signaled)
+		}
+	}
+}
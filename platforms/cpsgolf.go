@@ -8,7 +8,9 @@ import (
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -79,11 +81,11 @@ func setCPSHeaders(req *http.Request, config CPSGolfCourseConfig) {
 	req.Header.Set("x-moduleid", "7")
 	req.Header.Set("x-productid", "1")
 	req.Header.Set("x-terminalid", "3")
-	req.Header.Set("x-timezone-offset", "420")
 	tz := config.Timezone
 	if tz == "" {
 		tz = "America/Denver"
 	}
+	req.Header.Set("x-timezone-offset", strconv.Itoa(cpsTimezoneOffset(tz)))
 	req.Header.Set("x-timezoneid", tz)
 	req.Header.Set("x-ismobile", "false")
 	req.Header.Set("client-id", "onlineresweb")
@@ -100,20 +102,86 @@ func setCPSHeaders(req *http.Request, config CPSGolfCourseConfig) {
 	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
 }
 
-func FetchCPSGolf(config CPSGolfCourseConfig, date string) ([]DisplayTeeTime, error) {
+// cpsSession holds the per-site state CPS requires before a TeeTimes query:
+// the apiKey or bearer token, the resolved course IDs and a registered
+// transaction. Sessions are reused until they expire.
+type cpsSession struct {
+	mu          sync.Mutex
+	client      *http.Client
+	apiKey      string
+	bearerToken string
+	courseIDs   string
+	txnID       string
+	expires     time.Time
+}
+
+// cpsSessionTTL caps session reuse when the token response gives no expiry.
+const cpsSessionTTL = 20 * time.Minute
+
+var cpsSessions = struct {
+	sync.Mutex
+	m map[string]*cpsSession
+}{m: map[string]*cpsSession{}}
+
+// cpsTimezoneOffset returns the browser-style offset (minutes behind UTC)
+// for the configured timezone, e.g. 420 for America/Phoenix.
+func cpsTimezoneOffset(tz string) int {
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return 420
+	}
+	_, offset := time.Now().In(loc).Zone()
+	return -offset / 60
+}
+
+// getCPSSession returns a live session for the config, creating or renewing
+// it if needed. Concurrent callers for the same site share one refresh.
+func getCPSSession(config CPSGolfCourseConfig) (*cpsSession, error) {
+	cpsSessions.Lock()
+	sess, ok := cpsSessions.m[config.Key]
+	if !ok {
+		sess = &cpsSession{}
+		cpsSessions.m[config.Key] = sess
+	}
+	cpsSessions.Unlock()
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if sess.client != nil && time.Now().Before(sess.expires) {
+		return sess, nil
+	}
+
+	err := sess.refresh(config)
+	if err != nil {
+		sess.client = nil
+		return nil, err
+	}
+	return sess, nil
+}
+
+// invalidateCPSSession drops a session so the next fetch starts fresh.
+func invalidateCPSSession(key string) {
+	cpsSessions.Lock()
+	delete(cpsSessions.m, key)
+	cpsSessions.Unlock()
+}
+
+func (sess *cpsSession) refresh(config CPSGolfCourseConfig) error {
 	jar, _ := cookiejar.New(nil)
-	client := http.Client{Jar: jar, Timeout: PlatformTimeout}
+	client := &http.Client{Jar: jar, Timeout: PlatformTimeout}
+	var expires time.Time = time.Now().Add(cpsSessionTTL)
 
 	// Step 1: Fetch Configuration to get apiKey dynamically
 	configReq, err := http.NewRequest("GET", config.BaseURL+"/onlineresweb/Home/Configuration", nil)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	configReq.Header.Set("Accept", "application/json, text/plain, */*")
 	configReq.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/145.0.0.0 Safari/537.36")
 	configResp, err := client.Do(configReq)
 	if err != nil {
-		return nil, fmt.Errorf("CPS Golf %s: config fetch error: %w", config.Key, err)
+		return fmt.Errorf("CPS Golf %s: config fetch error: %w", config.Key, err)
 	}
 	configBody, _ := io.ReadAll(configResp.Body)
 	configResp.Body.Close()
@@ -132,22 +200,30 @@ func FetchCPSGolf(config CPSGolfCourseConfig, date string) ([]DisplayTeeTime, er
 		form := url.Values{"client_id": {"onlinereswebshortlived"}}
 		tokenResp, err := client.PostForm(config.BaseURL+"/identityapi/myconnect/token/short", form)
 		if err != nil {
-			return nil, fmt.Errorf("CPS Golf %s: token error: %w", config.Key, err)
+			return fmt.Errorf("CPS Golf %s: token error: %w", config.Key, err)
 		}
 		tokenBody, _ := io.ReadAll(tokenResp.Body)
 		tokenResp.Body.Close()
 		var tok struct {
 			AccessToken string `json:"access_token"`
+			ExpiresIn   int    `json:"expires_in"`
 		}
 		json.Unmarshal(tokenBody, &tok)
 		bearerToken = tok.AccessToken
+		// Renew a minute early so a query never goes out with a dying token
+		if tok.ExpiresIn > 60 {
+			tokenExpiry := time.Now().Add(time.Duration(tok.ExpiresIn-60) * time.Second)
+			if tokenExpiry.Before(expires) {
+				expires = tokenExpiry
+			}
+		}
 	}
 
-	// Step 2: Fetch OnlineCourses to get courseIds dynamically
+	// Step 2: Fetch OnlineCourses to get every courseId dynamically
 	if config.CourseIDs == "" {
 		coursesReq, err := http.NewRequest("GET", config.BaseURL+"/onlineres/onlineapi/api/v1/onlinereservation/OnlineCourses", nil)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		setCPSHeaders(coursesReq, config)
 		if bearerToken != "" {
@@ -155,7 +231,7 @@ func FetchCPSGolf(config CPSGolfCourseConfig, date string) ([]DisplayTeeTime, er
 		}
 		coursesResp, err := client.Do(coursesReq)
 		if err != nil {
-			return nil, fmt.Errorf("CPS Golf %s: courses fetch error: %w", config.Key, err)
+			return fmt.Errorf("CPS Golf %s: courses fetch error: %w", config.Key, err)
 		}
 		coursesBody, _ := io.ReadAll(coursesResp.Body)
 		coursesResp.Body.Close()
@@ -168,22 +244,20 @@ func FetchCPSGolf(config CPSGolfCourseConfig, date string) ([]DisplayTeeTime, er
 		for _, c := range courses {
 			ids = append(ids, fmt.Sprintf("%d", c.CourseID))
 		}
-		if len(ids) > 0 {
-			config.CourseIDs = ids[0]
-		}
+		config.CourseIDs = strings.Join(ids, ",")
 	}
 
 	// Step 3: Register a transaction ID
 	var txnID string = generateUUID()
 	txnBody, err := json.Marshal(map[string]string{"transactionId": txnID})
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	var txnReq *http.Request
 	txnReq, err = http.NewRequest("POST", config.BaseURL+"/onlineres/onlineapi/api/v1/onlinereservation/RegisterTransactionId", bytes.NewBuffer(txnBody))
 	if err != nil {
-		return nil, err
+		return err
 	}
 	txnReq.Header.Set("Content-Type", "application/json")
 	setCPSHeaders(txnReq, config)
@@ -194,11 +268,50 @@ func FetchCPSGolf(config CPSGolfCourseConfig, date string) ([]DisplayTeeTime, er
 	var txnResp *http.Response
 	txnResp, err = client.Do(txnReq)
 	if err != nil {
-		return nil, fmt.Errorf("CPS Golf %s: txn register error: %w", config.Key, err)
+		return fmt.Errorf("CPS Golf %s: txn register error: %w", config.Key, err)
 	}
 	txnResp.Body.Close()
 
-	// Step 4: Fetch tee times
+	sess.client = client
+	sess.apiKey = config.APIKey
+	sess.bearerToken = bearerToken
+	sess.courseIDs = config.CourseIDs
+	sess.txnID = txnID
+	sess.expires = expires
+	return nil
+}
+
+func FetchCPSGolf(config CPSGolfCourseConfig, date string) ([]DisplayTeeTime, error) {
+	results, retry, err := fetchCPSGolfTeeTimes(config, date)
+	if retry {
+		// Session was rejected upstream — start over once with a fresh one
+		invalidateCPSSession(config.Key)
+		results, retry, err = fetchCPSGolfTeeTimes(config, date)
+		if retry {
+			// A fresh session rejected too isn't the same as an empty sheet
+			invalidateCPSSession(config.Key)
+			return nil, fmt.Errorf("CPS Golf %s: session rejected after renewal", config.Key)
+		}
+	}
+	return results, err
+}
+
+// fetchCPSGolfTeeTimes runs the TeeTimes query on the cached session. retry
+// reports that the session was rejected and should be renewed.
+func fetchCPSGolfTeeTimes(config CPSGolfCourseConfig, date string) ([]DisplayTeeTime, bool, error) {
+	sess, err := getCPSSession(config)
+	if err != nil {
+		return nil, false, err
+	}
+	sess.mu.Lock()
+	var client *http.Client = sess.client
+	var bearerToken string = sess.bearerToken
+	var txnID string = sess.txnID
+	config.APIKey = sess.apiKey
+	config.CourseIDs = sess.courseIDs
+	sess.mu.Unlock()
+
+	// Fetch tee times on the session
 	var searchDate string = formatCPSDate(date)
 	var encodedDate string = url.PathEscape(searchDate)
 	var teeURL string = fmt.Sprintf(
@@ -209,7 +322,7 @@ func FetchCPSGolf(config CPSGolfCourseConfig, date string) ([]DisplayTeeTime, er
 	var req *http.Request
 	req, err = http.NewRequest("GET", teeURL, nil)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	setCPSHeaders(req, config)
 	if bearerToken != "" {
@@ -220,18 +333,21 @@ func FetchCPSGolf(config CPSGolfCourseConfig, date string) ([]DisplayTeeTime, er
 	var resp *http.Response
 	resp, err = client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("CPS Golf %s: HTTP error: %w", config.Key, err)
+		return nil, false, fmt.Errorf("CPS Golf %s: HTTP error: %w", config.Key, err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == 401 || resp.StatusCode == 403 {
+		return nil, true, nil
+	}
 	if resp.StatusCode != 200 {
-		return nil, nil
+		return nil, false, nil
 	}
 
 	var body []byte
 	body, err = io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	var slots []CPSGolfSlot
@@ -239,12 +355,12 @@ func FetchCPSGolf(config CPSGolfCourseConfig, date string) ([]DisplayTeeTime, er
 	var data CPSGolfResponse
 	if json.Unmarshal(body, &data) == nil && len(data.Content) > 0 {
 		if json.Unmarshal(data.Content, &slots) != nil {
-			return nil, nil
+			return nil, false, nil
 		}
 	} else {
 		// Fall back to raw list format: [...]
 		if json.Unmarshal(body, &slots) != nil {
-			return nil, nil
+			return nil, false, nil
 		}
 	}
 
@@ -285,5 +401,5 @@ func FetchCPSGolf(config CPSGolfCourseConfig, date string) ([]DisplayTeeTime, er
 		})
 	}
 
-	return results, nil
+	return results, false, nil
 }
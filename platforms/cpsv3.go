@@ -0,0 +1,243 @@
+package platforms
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// CPSV3CourseConfig describes a legacy CPS Golf V3 site (e.cps.golf/{Name}V3),
+// which renders the tee sheet server-side instead of exposing the REST API.
+// CourseIDs are the site's own CourseId values, comma separated.
+type CPSV3CourseConfig struct {
+	Key        string            `json:"key"`
+	Metro      string            `json:"metro"`
+	BaseURL    string            `json:"baseUrl"`
+	CourseIDs  string            `json:"courseIds"`
+	BookingURL string            `json:"bookingUrl"`
+	Names      map[string]string `json:"names"`
+	City       string            `json:"city"`
+	State      string            `json:"state"`
+}
+
+var CPSV3Courses = map[string]CPSV3CourseConfig{}
+
+// ErrCPSV3Layout is returned when a page lacks the V3 search form or a
+// result can't be read, so a markup change fails loudly.
+var ErrCPSV3Layout = errors.New("cps v3: tee sheet layout changed")
+
+var (
+	v3TimeRe    = regexp.MustCompile(`^(\d{1,2}:\d{2})\s*([AP]M)$`)
+	v3HolesRe   = regexp.MustCompile(`(\d+)\s*Holes`)
+	v3PlayersRe = regexp.MustCompile(`(?:(\d)\s*-\s*)?(\d)\s*Players?`)
+	v3PriceRe   = regexp.MustCompile(`\$\s*([\d,]+\.\d{2})`)
+)
+
+// CPSV3Course is one entry of the search form's course picker.
+type CPSV3Course struct {
+	ID   string
+	Name string
+}
+
+// CPSV3Row is one search result.
+type CPSV3Row struct {
+	Time    string
+	Course  string
+	Holes   string
+	Players int
+	Price   float64
+}
+
+// CPSV3Page is a parsed /Home/nIndex page.
+type CPSV3Page struct {
+	Courses []CPSV3Course
+	Rows    []CPSV3Row
+}
+
+// ParseCPSV3Page reads a V3 tee sheet. A V3 page is recognised by its search
+// form posting to nIndex with a CourseId picker; without them the page is
+// something else (or changed) and ErrCPSV3Layout is returned. A form with
+// no results is an empty sheet.
+func ParseCPSV3Page(r io.Reader) (CPSV3Page, error) {
+	var page CPSV3Page
+	doc, err := html.Parse(r)
+	if err != nil {
+		return page, err
+	}
+
+	form := htmlFindFunc(doc, func(n *html.Node) bool {
+		return n.Type == html.ElementNode && n.Data == "form" && strings.Contains(strings.ToLower(htmlAttr(n, "action")), "nindex")
+	})
+	if form == nil {
+		return page, fmt.Errorf("%w: no search form", ErrCPSV3Layout)
+	}
+	picker := htmlFindFunc(form, func(n *html.Node) bool {
+		return n.Type == html.ElementNode && n.Data == "select" && strings.EqualFold(htmlAttr(n, "name"), "CourseId")
+	})
+	if picker == nil {
+		return page, fmt.Errorf("%w: no course picker", ErrCPSV3Layout)
+	}
+	for opt := picker.FirstChild; opt != nil; opt = opt.NextSibling {
+		if opt.Type != html.ElementNode || opt.Data != "option" {
+			continue
+		}
+		var id string = strings.TrimSpace(htmlAttr(opt, "value"))
+		if id == "" {
+			continue
+		}
+		page.Courses = append(page.Courses, CPSV3Course{ID: id, Name: htmlText(opt)})
+	}
+
+	var layoutErr error
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if layoutErr != nil {
+			return
+		}
+		if htmlHasClass(n, "search-result") {
+			row, err := parseCPSV3Row(n)
+			if err != nil {
+				layoutErr = err
+				return
+			}
+			page.Rows = append(page.Rows, row)
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	if layoutErr != nil {
+		return page, layoutErr
+	}
+	return page, nil
+}
+
+// parseCPSV3Row reads one result block as a unit, so a missing field can't
+// shift values between rows.
+func parseCPSV3Row(n *html.Node) (CPSV3Row, error) {
+	var row CPSV3Row = CPSV3Row{Holes: "18", Players: 4}
+
+	timeCell := htmlFind(n, "time")
+	if timeCell == nil {
+		return row, fmt.Errorf("%w: result without a time", ErrCPSV3Layout)
+	}
+	m := v3TimeRe.FindStringSubmatch(strings.ToUpper(htmlText(timeCell)))
+	if m == nil {
+		return row, fmt.Errorf("%w: unreadable tee time %q", ErrCPSV3Layout, htmlText(timeCell))
+	}
+	row.Time = m[1] + " " + m[2]
+
+	if courseCell := htmlFind(n, "course-name"); courseCell != nil {
+		row.Course = htmlText(courseCell)
+	}
+
+	var text string = htmlText(n)
+	if m := v3HolesRe.FindStringSubmatch(text); m != nil {
+		row.Holes = m[1]
+	}
+	if m := v3PlayersRe.FindStringSubmatch(text); m != nil {
+		row.Players, _ = strconv.Atoi(m[2])
+	}
+	if m := v3PriceRe.FindStringSubmatch(text); m != nil {
+		row.Price, _ = strconv.ParseFloat(strings.ReplaceAll(m[1], ",", ""), 64)
+	}
+	return row, nil
+}
+
+// FetchCPSV3 loads the V3 search results page once per configured course.
+func FetchCPSV3(config CPSV3CourseConfig, date string) ([]DisplayTeeTime, error) {
+	jar, _ := cookiejar.New(nil)
+	client := http.Client{Jar: jar, Timeout: PlatformTimeout}
+
+	parsedDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, err
+	}
+
+	var courseIDs []string
+	for _, id := range strings.Split(config.CourseIDs, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			courseIDs = append(courseIDs, id)
+		}
+	}
+	if len(courseIDs) == 0 {
+		return nil, fmt.Errorf("CPS V3 %s: no courseIds configured", config.Key)
+	}
+
+	var results []DisplayTeeTime
+	for _, courseID := range courseIDs {
+		params := url.Values{
+			"CourseId": {courseID},
+			"Date":     {parsedDate.Format("1/2/2006")},
+			"Time":     {"AnyTime"},
+			"Player":   {"99"},
+			"Hole":     {"Any"},
+		}
+
+		req, err := http.NewRequest("GET", strings.TrimRight(config.BaseURL, "/")+"/Home/nIndex?"+params.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/145.0.0.0 Safari/537.36")
+		req.Header.Set("Accept", "text/html")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("CPS V3 %s: %w", config.Key, err)
+		}
+		if resp.StatusCode != 200 {
+			resp.Body.Close()
+			return nil, fmt.Errorf("CPS V3 %s: HTTP %d", config.Key, resp.StatusCode)
+		}
+		page, err := ParseCPSV3Page(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("CPS V3 %s: %w", config.Key, err)
+		}
+
+		// Results without a course label belong to the course asked for
+		var pickerName string
+		for _, c := range page.Courses {
+			if c.ID == courseID {
+				pickerName = c.Name
+			}
+		}
+		for _, row := range page.Rows {
+			var label string = row.Course
+			if label == "" {
+				label = pickerName
+			}
+			var courseName string = config.Names[label]
+			if courseName == "" {
+				courseName = config.Names[courseID]
+			}
+			if courseName == "" {
+				courseName = label
+			}
+
+			results = append(results, DisplayTeeTime{
+				Time:       row.Time,
+				Course:     courseName,
+				City:       config.City,
+				State:      config.State,
+				Openings:   row.Players,
+				Holes:      row.Holes,
+				Price:      row.Price,
+				BookingURL: config.BookingURL,
+			})
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,78 @@
+package platforms
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func parseCPSV3Fixture(t *testing.T, name string) (CPSV3Page, error) {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	return ParseCPSV3Page(f)
+}
+
+func TestParseCPSV3PageResults(t *testing.T) {
+	page, err := parseCPSV3Fixture(t, "cpsv3_results.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wantCourses []CPSV3Course = []CPSV3Course{
+		{ID: "3", Name: "West Ridge - Championship"},
+		{ID: "4", Name: "West Ridge - Executive"},
+	}
+	if len(page.Courses) != len(wantCourses) {
+		t.Fatalf("got %d courses, want %d", len(page.Courses), len(wantCourses))
+	}
+	for i, c := range wantCourses {
+		if page.Courses[i] != c {
+			t.Errorf("course %d = %+v, want %+v", i, page.Courses[i], c)
+		}
+	}
+
+	var want []CPSV3Row = []CPSV3Row{
+		{Time: "7:10 AM", Course: "West Ridge - Championship", Holes: "18", Players: 4, Price: 52},
+		{Time: "7:20 AM", Course: "", Holes: "9", Players: 2, Price: 1024.5},
+	}
+	if len(page.Rows) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(page.Rows), len(want))
+	}
+	for i, row := range want {
+		if page.Rows[i] != row {
+			t.Errorf("row %d = %+v, want %+v", i, page.Rows[i], row)
+		}
+	}
+}
+
+func TestParseCPSV3PageEmpty(t *testing.T) {
+	page, err := parseCPSV3Fixture(t, "cpsv3_empty.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Rows) != 0 {
+		t.Errorf("got %d rows, want none", len(page.Rows))
+	}
+	if len(page.Courses) != 1 {
+		t.Errorf("got %d courses, want 1", len(page.Courses))
+	}
+}
+
+func TestParseCPSV3PageChangedLayout(t *testing.T) {
+	_, err := parseCPSV3Fixture(t, "cpsv3_changed.html")
+	if !errors.Is(err, ErrCPSV3Layout) {
+		t.Fatalf("err = %v, want ErrCPSV3Layout", err)
+	}
+}
+
+func TestParseCPSV3PageNotV3(t *testing.T) {
+	_, err := ParseCPSV3Page(strings.NewReader("<html><body><h1>Server Error in '/' Application.</h1></body></html>"))
+	if !errors.Is(err, ErrCPSV3Layout) {
+		t.Fatalf("err = %v, want ErrCPSV3Layout", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"embed"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -25,6 +26,29 @@ func loadJSON[T any](filename string) []T {
 	return list
 }
 
+// joinNames is the display name of an entry that covers several courses.
+func joinNames(names ...string) string {
+	var seen map[string]bool = make(map[string]bool)
+	var unique []string
+	for _, n := range names {
+		if n != "" && !seen[n] {
+			seen[n] = true
+			unique = append(unique, n)
+		}
+	}
+	sort.Strings(unique)
+	return strings.Join(unique, " / ")
+}
+
+// mapNames returns the display names of a Names map.
+func mapNames(names map[string]string) []string {
+	var list []string
+	for _, n := range names {
+		list = append(list, n)
+	}
+	return list
+}
+
 func init() {
 	for _, c := range loadJSON[TeeItUpCourseConfig]("data/teeitup.json") {
 		TeeItUpCourses[c.Key] = c
@@ -41,6 +65,9 @@ func init() {
 	for _, c := range loadJSON[CPSGolfCourseConfig]("data/cpsgolf.json") {
 		CPSGolfCourses[c.Key] = c
 	}
+	for _, c := range loadJSON[CPSV3CourseConfig]("data/cpsv3.json") {
+		CPSV3Courses[c.Key] = c
+	}
 	for _, c := range loadJSON[MemberSportsCourseConfig]("data/membersports.json") {
 		MemberSportsCourses[c.Key] = c
 	}
@@ -102,7 +129,7 @@ func init() {
 	// --- Populate global Registry ---
 	reg := func(key, metro, city, displayName, bookingURL string, enabled bool, fetch func(string) ([]DisplayTeeTime, error)) {
 		Registry = append(Registry, CourseEntry{
-			Key: key, Metro: metro, City: city,
+			Key: key, Name: displayName, Metro: metro, City: city,
 			Match:      func(name string) bool { return displayName == name },
 			BookingURL: bookingURL, Enabled: enabled, Fetch: fetch,
 		})
@@ -121,9 +148,6 @@ func init() {
 	for _, c := range CourseRevCourses {
 		reg(c.Key, c.Metro, c.City, c.DisplayName, c.BookingURL, true, func(d string) ([]DisplayTeeTime, error) { return FetchCourseRev(c, d) })
 	}
-	for _, c := range ForeUpCourses {
-		reg(c.Key, c.Metro, c.City, c.DisplayName, c.BookingURL, true, func(d string) ([]DisplayTeeTime, error) { return FetchForeUp(c, d) })
-	}
 	for _, c := range GolfBackCourses {
 		reg(c.Key, c.Metro, c.City, c.DisplayName, c.BookingURL, true, func(d string) ([]DisplayTeeTime, error) { return FetchGolfBack(c, d) })
 	}
@@ -166,11 +190,45 @@ func init() {
 		reg(c.Key, c.Metro, c.City, c.DisplayName, c.BookingURL, false, func(d string) ([]DisplayTeeTime, error) { return FetchProphet(c, d) })
 	}
 
-	// Platforms with Names-map matching (Chronogolf, CPSGolf, MemberSports)
+	// ForeUp — one entry per course, matching any of its schedule names
+	for _, c := range ForeUpCourses {
+		c := c
+		Registry = append(Registry, CourseEntry{
+			Key: c.Key, Name: c.DisplayName, Metro: c.Metro, City: c.City, BookingURL: c.BookingURL, Enabled: true,
+			Fetch: func(d string) ([]DisplayTeeTime, error) { return FetchForeUp(c, d) },
+			Match: func(name string) bool {
+				if c.DisplayName == name {
+					return true
+				}
+				for _, sched := range c.AllSchedules() {
+					if sched.DisplayName == name {
+						return true
+					}
+				}
+				return false
+			},
+		})
+		for _, sched := range c.AllSchedules() {
+			if sched.Key == "" || sched.Key == c.Key {
+				continue
+			}
+			var name string = sched.DisplayName
+			courseKeyAliases[sched.Key] = courseKeyAlias{
+				parent: c.Key,
+				entry: CourseEntry{
+					Key: sched.Key, Name: name, Metro: c.Metro, City: c.City, BookingURL: c.BookingURL, Enabled: true,
+					Fetch: func(d string) ([]DisplayTeeTime, error) { return FetchForeUp(c, d) },
+					Match: func(n string) bool { return n == name },
+				},
+			}
+		}
+	}
+
+	// Platforms with Names-map matching (Chronogolf, CPSGolf, CPS V3, MemberSports)
 	for _, c := range ChronogolfCourses {
 		c := c
 		Registry = append(Registry, CourseEntry{
-			Key: c.Key, Metro: c.Metro, City: c.City, BookingURL: c.BookingURL, Enabled: true,
+			Key: c.Key, Name: joinNames(mapNames(c.Names)...), Metro: c.Metro, City: c.City, BookingURL: c.BookingURL, Enabled: true,
 			Fetch: func(d string) ([]DisplayTeeTime, error) { return FetchChronogolf(c, d) },
 			Match: func(name string) bool {
 				for _, dn := range c.Names {
@@ -185,7 +243,7 @@ func init() {
 	for _, c := range CPSGolfCourses {
 		c := c
 		Registry = append(Registry, CourseEntry{
-			Key: c.Key, Metro: c.Metro, City: c.City, BookingURL: c.BookingURL, Enabled: true,
+			Key: c.Key, Name: joinNames(mapNames(c.Names)...), Metro: c.Metro, City: c.City, BookingURL: c.BookingURL, Enabled: true,
 			Fetch: func(d string) ([]DisplayTeeTime, error) { return FetchCPSGolf(c, d) },
 			Match: func(name string) bool {
 				for _, dn := range c.Names {
@@ -197,10 +255,25 @@ func init() {
 			},
 		})
 	}
+	for _, c := range CPSV3Courses {
+		c := c
+		Registry = append(Registry, CourseEntry{
+			Key: c.Key, Name: joinNames(mapNames(c.Names)...), Metro: c.Metro, City: c.City, BookingURL: c.BookingURL, Enabled: true,
+			Fetch: func(d string) ([]DisplayTeeTime, error) { return FetchCPSV3(c, d) },
+			Match: func(name string) bool {
+				for _, dn := range c.Names {
+					if dn == name {
+						return true
+					}
+				}
+				return false
+			},
+		})
+	}
 	for _, c := range MemberSportsCourses {
 		c := c
 		Registry = append(Registry, CourseEntry{
-			Key: c.Key, Metro: c.Metro, City: c.City, BookingURL: c.BookingURL, Enabled: true,
+			Key: c.Key, Name: joinNames(c.KnownCourses...), Metro: c.Metro, City: c.City, BookingURL: c.BookingURL, Enabled: true,
 			Fetch: func(d string) ([]DisplayTeeTime, error) { return FetchMemberSports(c, d) },
 			Match: func(name string) bool {
 				for _, kn := range c.KnownCourses {
@@ -217,7 +290,7 @@ func init() {
 	for _, c := range TeeItUpCourses {
 		c := c
 		Registry = append(Registry, CourseEntry{
-			Key: c.Key, Metro: c.Metro, City: c.City, Enabled: true,
+			Key: c.Key, Name: c.DisplayName, Metro: c.Metro, City: c.City, Enabled: true,
 			BookingURL: "https://" + c.Alias + ".book.teeitup.com/teetimes",
 			Fetch:      func(d string) ([]DisplayTeeTime, error) { return FetchTeeItUp(c, d) },
 			Match: func(name string) bool {
@@ -238,7 +311,7 @@ func init() {
 	for _, c := range Quick18Courses {
 		c := c
 		Registry = append(Registry, CourseEntry{
-			Key: c.Key, Metro: c.Metro, City: c.City, BookingURL: c.BookingURL, Enabled: true,
+			Key: c.Key, Name: c.DisplayName, Metro: c.Metro, City: c.City, BookingURL: c.BookingURL, Enabled: true,
 			Fetch: func(d string) ([]DisplayTeeTime, error) { return FetchQuick18(c, d) },
 			Match: func(name string) bool {
 				if c.DisplayName == name {
@@ -253,11 +326,15 @@ func init() {
 	for _, s := range GuestDeskCourses {
 		s := s
 		city := ""
+		var names []string
+		for _, gc := range s.Courses {
+			names = append(names, gc.DisplayName)
+		}
 		if len(s.Courses) > 0 {
 			city = s.Courses[0].City
 		}
 		Registry = append(Registry, CourseEntry{
-			Key: s.Key, Metro: s.Metro, City: city, BookingURL: s.BookingURL, Enabled: true,
+			Key: s.Key, Name: joinNames(names...), Metro: s.Metro, City: city, BookingURL: s.BookingURL, Enabled: true,
 			Fetch: func(d string) ([]DisplayTeeTime, error) { return FetchGuestDesk(s, d) },
 			Match: func(name string) bool {
 				for _, gc := range s.Courses {
@@ -269,6 +346,16 @@ func init() {
 			},
 		})
 	}
+
+	// Booking windows — attached to entries by key
+	var windows map[string]*BookingWindow = map[string]*BookingWindow{}
+	for _, w := range loadJSON[BookingWindow]("data/booking_windows.json") {
+		w := w
+		windows[w.Key] = &w
+	}
+	for i := range Registry {
+		Registry[i].Window = windows[Registry[i].Key]
+	}
 }
 
 type metroStat struct {
@@ -298,6 +385,9 @@ func MetroStats() map[string][2]int {
 	for _, c := range CPSGolfCourses {
 		ensure(c.Metro, c.City)
 	}
+	for _, c := range CPSV3Courses {
+		ensure(c.Metro, c.City)
+	}
 	for _, c := range GolfNowCourses {
 		ensure(c.Metro, c.City)
 	}
@@ -323,7 +413,9 @@ func MetroStats() map[string][2]int {
 		ensure(c.Metro, c.City)
 	}
 	for _, c := range ForeUpCourses {
-		ensure(c.Metro, c.City)
+		for range c.AllSchedules() {
+			ensure(c.Metro, c.City)
+		}
 	}
 	// Prophet disabled — AWS WAF blocks most requests
 	// for _, c := range ProphetCourses {
@@ -2,23 +2,47 @@ package platforms
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"regexp"
+	"strings"
+	"sync"
 	"time"
 )
 
-type ForeUpCourseConfig struct {
-	Key          string `json:"key"`
-	Metro        string `json:"metro"`
-	CourseID     string `json:"courseId"`
-	BookingClass string `json:"bookingClass"`
+// ForeUpSchedule is one tee sheet (a course, or nine, executive, ...) of a
+// ForeUP facility. BookingClass overrides the facility-level class when set.
+// Key is the registry key the schedule had when it was configured as its own
+// course; it stays resolvable so saved alerts and links keep working.
+type ForeUpSchedule struct {
+	Key          string `json:"key,omitempty"`
 	ScheduleID   string `json:"scheduleId"`
-	BookingURL   string `json:"bookingUrl"`
 	DisplayName  string `json:"displayName"`
-	City         string `json:"city"`
-	State        string `json:"state"`
+	BookingClass string `json:"bookingClass,omitempty"`
+}
+
+type ForeUpCourseConfig struct {
+	Key          string           `json:"key"`
+	Metro        string           `json:"metro"`
+	CourseID     string           `json:"courseId"`
+	BookingClass string           `json:"bookingClass"`
+	ScheduleID   string           `json:"scheduleId,omitempty"`
+	Schedules    []ForeUpSchedule `json:"schedules,omitempty"`
+	BookingURL   string           `json:"bookingUrl"`
+	DisplayName  string           `json:"displayName"`
+	City         string           `json:"city"`
+	State        string           `json:"state"`
+}
+
+// AllSchedules returns the configured schedules, treating the single
+// ScheduleID/DisplayName form as a one-schedule list.
+func (c ForeUpCourseConfig) AllSchedules() []ForeUpSchedule {
+	if len(c.Schedules) > 0 {
+		return c.Schedules
+	}
+	return []ForeUpSchedule{{ScheduleID: c.ScheduleID, DisplayName: c.DisplayName}}
 }
 
 var ForeUpCourses = map[string]ForeUpCourseConfig{}
@@ -31,31 +55,103 @@ type ForeUpTeeTime struct {
 	CourseName     string  `json:"course_name"`
 }
 
+// ErrForeUpLoginRequired means every public booking class for the course
+// requires a customer login, so tee times can't be listed anonymously.
+var ErrForeUpLoginRequired = errors.New("foreup: booking class requires login")
+
 var foreUpClassRe = regexp.MustCompile(`"booking_class"\s*:\s*"?(\w+)"?`)
+var foreUpClassObjRe = regexp.MustCompile(`\{[^{}]*"booking_class_id"[^{}]*\}`)
+
+type foreUpBookingClass struct {
+	ID        json.Number `json:"booking_class_id"`
+	Name      string      `json:"name"`
+	Protected json.Number `json:"online_booking_protected"`
+}
+
+// Resolved booking classes per course ID — the booking page rarely changes,
+// so scrape it at most once per TTL instead of on every fetch.
+var foreUpClassCache struct {
+	sync.Mutex
+	entries map[string]foreUpClassEntry
+}
 
-func resolveBookingClass(client *http.Client, courseID string) string {
+type foreUpClassEntry struct {
+	class    string
+	err      error
+	resolved time.Time
+}
+
+const foreUpClassCacheTTL = 6 * time.Hour
+
+func init() {
+	foreUpClassCache.entries = make(map[string]foreUpClassEntry)
+}
+
+func cachedBookingClass(client *http.Client, courseID string) (string, error) {
+	foreUpClassCache.Lock()
+	entry, ok := foreUpClassCache.entries[courseID]
+	foreUpClassCache.Unlock()
+	if ok && time.Since(entry.resolved) < foreUpClassCacheTTL {
+		return entry.class, entry.err
+	}
+
+	class, err := resolveBookingClass(client, courseID)
+	if class == "" && err == nil {
+		// Transient scrape failure — don't cache, try again next fetch
+		return "", nil
+	}
+
+	foreUpClassCache.Lock()
+	foreUpClassCache.entries[courseID] = foreUpClassEntry{class: class, err: err, resolved: time.Now()}
+	foreUpClassCache.Unlock()
+	return class, err
+}
+
+// resolveBookingClass scrapes the booking page for the first booking class
+// open to the public. If classes are listed but all are login-protected it
+// returns ErrForeUpLoginRequired.
+func resolveBookingClass(client *http.Client, courseID string) (string, error) {
 	url := fmt.Sprintf("https://foreupsoftware.com/index.php/booking/index/%s", courseID)
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return ""
+		return "", nil
 	}
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36")
 	resp, err := client.Do(req)
 	if err != nil {
-		return ""
+		return "", nil
 	}
 	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return ""
+		return "", nil
 	}
+
+	var sawProtected bool
+	for _, obj := range foreUpClassObjRe.FindAllString(string(body), -1) {
+		var bc foreUpBookingClass
+		if json.Unmarshal([]byte(obj), &bc) != nil || bc.ID == "" {
+			continue
+		}
+		if bc.Protected == "1" {
+			sawProtected = true
+			continue
+		}
+		return bc.ID.String(), nil
+	}
+	if sawProtected {
+		return "", ErrForeUpLoginRequired
+	}
+
 	m := foreUpClassRe.FindStringSubmatch(string(body))
 	if len(m) > 1 {
-		return m[1]
+		return m[1], nil
 	}
-	return ""
+	return "", nil
 }
 
+// FetchForeUp queries every schedule of the course and labels each tee time
+// with its schedule's display name.
 func FetchForeUp(config ForeUpCourseConfig, date string) ([]DisplayTeeTime, error) {
 	var t time.Time
 	var err error
@@ -67,18 +163,49 @@ func FetchForeUp(config ForeUpCourseConfig, date string) ([]DisplayTeeTime, erro
 
 	var client http.Client = http.Client{Timeout: PlatformTimeout}
 
-	// Resolve booking class if not set
-	var bookingClass string = config.BookingClass
-	if bookingClass == "" {
-		bookingClass = resolveBookingClass(&client, config.CourseID)
+	var results []DisplayTeeTime
+	var firstErr error
+	for _, sched := range config.AllSchedules() {
+		// Resolve booking class if not set
+		var bookingClass string = sched.BookingClass
+		if bookingClass == "" {
+			bookingClass = config.BookingClass
+		}
+		if bookingClass == "" {
+			bookingClass, err = cachedBookingClass(&client, config.CourseID)
+			if err != nil {
+				return nil, fmt.Errorf("ForeUp %s: %w", config.Key, err)
+			}
+		}
+
+		var schedResults []DisplayTeeTime
+		schedResults, err = fetchForeUpSchedule(&client, config, sched, bookingClass, foreUpDate)
+		if errors.Is(err, ErrForeUpLoginRequired) {
+			return nil, fmt.Errorf("ForeUp %s: %w", config.Key, err)
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		results = append(results, schedResults...)
 	}
 
+	if results == nil && firstErr != nil {
+		return nil, firstErr
+	}
+	return results, nil
+}
+
+func fetchForeUpSchedule(client *http.Client, config ForeUpCourseConfig, sched ForeUpSchedule, bookingClass string, foreUpDate string) ([]DisplayTeeTime, error) {
 	var url string = fmt.Sprintf(
 		"https://foreupsoftware.com/index.php/api/booking/times?time=all&date=%s&holes=all&players=0&booking_class=%s&schedule_id=%s&schedule_ids%%5B%%5D=%s&specials_only=0&api_key=no_limits",
-		foreUpDate, bookingClass, config.ScheduleID, config.ScheduleID,
+		foreUpDate, bookingClass, sched.ScheduleID, sched.ScheduleID,
 	)
 
 	var req *http.Request
+	var err error
 	req, err = http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, err
@@ -95,6 +222,10 @@ func FetchForeUp(config ForeUpCourseConfig, date string) ([]DisplayTeeTime, erro
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == 401 || resp.StatusCode == 403 {
+		return nil, ErrForeUpLoginRequired
+	}
+
 	var body []byte
 	body, err = io.ReadAll(resp.Body)
 	if err != nil {
@@ -104,12 +235,16 @@ func FetchForeUp(config ForeUpCourseConfig, date string) ([]DisplayTeeTime, erro
 	var data []ForeUpTeeTime
 	err = json.Unmarshal(body, &data)
 	if err != nil {
+		// Protected classes answer with a message object instead of a list
+		if strings.Contains(strings.ToLower(string(body)), "log in") {
+			return nil, ErrForeUpLoginRequired
+		}
 		return nil, err
 	}
 
 	var bookingURL string = fmt.Sprintf(
 		"https://foreupsoftware.com/index.php/booking/%s/%s#teetimes",
-		config.CourseID, config.ScheduleID,
+		config.CourseID, sched.ScheduleID,
 	)
 
 	var results []DisplayTeeTime
@@ -132,7 +267,7 @@ func FetchForeUp(config ForeUpCourseConfig, date string) ([]DisplayTeeTime, erro
 
 		results = append(results, DisplayTeeTime{
 			Time:       timeStr,
-			Course:     config.DisplayName,
+			Course:     sched.DisplayName,
 			City:       config.City,
 			State:      config.State,
 			Openings:   tt.AvailableSpots,
@@ -0,0 +1,74 @@
+package platforms
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Helpers for the platforms that scrape server-rendered tee sheets with
+// x/net/html.
+
+func htmlHasClass(n *html.Node, class string) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	for _, c := range strings.Fields(htmlAttr(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+func htmlAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// htmlFind returns the first descendant of n (or n itself) with the class.
+func htmlFind(n *html.Node, class string) *html.Node {
+	if htmlHasClass(n, class) {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := htmlFind(c, class); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// htmlFindFunc returns the first descendant of n (or n itself) match accepts.
+func htmlFindFunc(n *html.Node, match func(*html.Node) bool) *html.Node {
+	if match(n) {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := htmlFindFunc(c, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// htmlText returns n's text with whitespace collapsed.
+func htmlText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+			b.WriteString(" ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
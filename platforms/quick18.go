@@ -1,12 +1,15 @@
 package platforms
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
+
+	"golang.org/x/net/html"
 )
 
 type Quick18CourseConfig struct {
@@ -24,16 +27,27 @@ type Quick18CourseConfig struct {
 
 var Quick18Courses = map[string]Quick18CourseConfig{}
 
-var quick18TimeRegex *regexp.Regexp = regexp.MustCompile(`mtrxTeeTimes">\s*(\d+:\d+)<div class="be_tee_time_ampm">(AM|PM)</div>`)
-var quick18CourseRegex *regexp.Regexp = regexp.MustCompile(`mtrxCourse">(.*?)</td>`)
-var quick18PlayersRegex *regexp.Regexp = regexp.MustCompile(`matrixPlayers">(.*?)</td>`)
-var quick18PriceRegex *regexp.Regexp = regexp.MustCompile(`mtrxPrice">\$([\d.]+)</div>`)
+// ErrQuick18Layout is returned when the search matrix no longer has the
+// structure the parser expects, so a markup change fails loudly instead of
+// silently producing wrong times or prices.
+var ErrQuick18Layout = errors.New("quick18: search matrix layout changed")
+
+var quick18NumRegex *regexp.Regexp = regexp.MustCompile(`(\d+)`)
+var quick18TimeRegex *regexp.Regexp = regexp.MustCompile(`^(\d{1,2}:\d{2})\s*(AM|PM)$`)
+var quick18PriceRegex *regexp.Regexp = regexp.MustCompile(`\$\s*([\d,]+(?:\.\d+)?)`)
+
+// quick18Row is one tee time row of the search matrix.
+type quick18Row struct {
+	Time    string
+	Course  string
+	Players int
+	Price   float64
+}
 
 func parseQuick18Players(text string) int {
 	// "1 to 4 players" -> 4, "1 or 2 players" -> 2, "1 player" -> 1
 	text = strings.TrimSpace(text)
-	var numRegex *regexp.Regexp = regexp.MustCompile(`(\d+)`)
-	var matches []string = numRegex.FindAllString(text, -1)
+	var matches []string = quick18NumRegex.FindAllString(text, -1)
 	if len(matches) == 0 {
 		return 1
 	}
@@ -47,6 +61,81 @@ func parseQuick18Players(text string) int {
 	return n
 }
 
+// parseQuick18Matrix walks the search matrix and reads each <tr> holding a
+// tee time cell as one unit. A page without the matrix table, or a row
+// missing its time, players or price cell, means the layout changed and
+// yields ErrQuick18Layout. The table renders even when nothing is open.
+func parseQuick18Matrix(r io.Reader) ([]quick18Row, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	if htmlFind(doc, "matrixTable") == nil {
+		return nil, fmt.Errorf("%w: no search matrix", ErrQuick18Layout)
+	}
+
+	var rows []quick18Row
+	var layoutErr error
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if layoutErr != nil {
+			return
+		}
+		if n.Type == html.ElementNode && n.Data == "tr" {
+			timeCell := htmlFind(n, "mtrxTeeTimes")
+			if timeCell != nil {
+				row, err := parseQuick18Row(n, timeCell)
+				if err != nil {
+					layoutErr = err
+					return
+				}
+				rows = append(rows, row)
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	if layoutErr != nil {
+		return nil, layoutErr
+	}
+	return rows, nil
+}
+
+func parseQuick18Row(tr *html.Node, timeCell *html.Node) (quick18Row, error) {
+	var row quick18Row
+
+	m := quick18TimeRegex.FindStringSubmatch(strings.ToUpper(htmlText(timeCell)))
+	if m == nil {
+		return row, fmt.Errorf("%w: unreadable tee time %q", ErrQuick18Layout, htmlText(timeCell))
+	}
+	row.Time = m[1] + " " + m[2]
+
+	if courseCell := htmlFind(tr, "mtrxCourse"); courseCell != nil {
+		row.Course = htmlText(courseCell)
+	}
+
+	playersCell := htmlFind(tr, "matrixPlayers")
+	if playersCell == nil {
+		return row, fmt.Errorf("%w: no players cell for %s", ErrQuick18Layout, row.Time)
+	}
+	row.Players = parseQuick18Players(htmlText(playersCell))
+
+	// Rows list one price per rate column; the first is the public rate
+	priceCell := htmlFind(tr, "mtrxPrice")
+	if priceCell == nil {
+		return row, fmt.Errorf("%w: no price cell for %s", ErrQuick18Layout, row.Time)
+	}
+	if pm := quick18PriceRegex.FindStringSubmatch(htmlText(priceCell)); pm != nil {
+		row.Price, _ = strconv.ParseFloat(strings.ReplaceAll(pm[1], ",", ""), 64)
+	}
+
+	return row, nil
+}
+
 func FetchQuick18(config Quick18CourseConfig, date string) ([]DisplayTeeTime, error) {
 	// Convert date from 2026-01-15 to 20260115
 	var dateClean string = strings.ReplaceAll(date, "-", "")
@@ -75,43 +164,31 @@ func FetchQuick18(config Quick18CourseConfig, date string) ([]DisplayTeeTime, er
 	}
 	defer resp.Body.Close()
 
-	var body []byte
-	body, err = io.ReadAll(resp.Body)
+	var rows []quick18Row
+	rows, err = parseQuick18Matrix(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("Quick18 %s: %w", config.Key, err)
 	}
 
-	var html string = string(body)
-
-	var times [][]string = quick18TimeRegex.FindAllStringSubmatch(html, -1)
-	var courses [][]string = quick18CourseRegex.FindAllStringSubmatch(html, -1)
-	var players [][]string = quick18PlayersRegex.FindAllStringSubmatch(html, -1)
-	var prices [][]string = quick18PriceRegex.FindAllStringSubmatch(html, -1)
-
 	var results []DisplayTeeTime
-	for i := 0; i < len(times); i++ {
-		var timeStr string = times[i][1] + " " + times[i][2]
-
+	for _, row := range rows {
 		var courseName string = config.DisplayName
 		var holes string = "18"
-		if i < len(courses) {
-			var htmlCourse string = strings.TrimSpace(courses[i][1])
-			if htmlCourse != "" {
-				lc := strings.ToLower(htmlCourse)
-				if strings.Contains(lc, "back 9") || strings.Contains(lc, "front 9") || strings.HasSuffix(lc, " 9") {
-					holes = "9"
-				}
-				if config.NamePrefix != "" {
-					if strings.HasPrefix(htmlCourse, config.NamePrefix) {
-						var suffix string = strings.TrimSpace(strings.TrimPrefix(htmlCourse, config.NamePrefix))
-						if suffix != "" {
-							courseName = config.NamePrefix + " - " + suffix
-						} else {
-							courseName = config.NamePrefix
-						}
+		if row.Course != "" {
+			lc := strings.ToLower(row.Course)
+			if strings.Contains(lc, "back 9") || strings.Contains(lc, "front 9") || strings.HasSuffix(lc, " 9") {
+				holes = "9"
+			}
+			if config.NamePrefix != "" {
+				if strings.HasPrefix(row.Course, config.NamePrefix) {
+					var suffix string = strings.TrimSpace(strings.TrimPrefix(row.Course, config.NamePrefix))
+					if suffix != "" {
+						courseName = config.NamePrefix + " - " + suffix
 					} else {
-						courseName = config.NamePrefix + " - " + htmlCourse
+						courseName = config.NamePrefix
 					}
+				} else {
+					courseName = config.NamePrefix + " - " + row.Course
 				}
 			}
 		}
@@ -119,24 +196,14 @@ func FetchQuick18(config Quick18CourseConfig, date string) ([]DisplayTeeTime, er
 			holes = config.Holes
 		}
 
-		var openings int = 1
-		if i < len(players) {
-			openings = parseQuick18Players(players[i][1])
-		}
-
-		var price float64 = 0
-		if i < len(prices) {
-			price, _ = strconv.ParseFloat(prices[i][1], 64)
-		}
-
 		results = append(results, DisplayTeeTime{
-			Time:       timeStr,
+			Time:       row.Time,
 			Course:     courseName,
 			City:       config.City,
 			State:      config.State,
-			Openings:   openings,
+			Openings:   row.Players,
 			Holes:      holes,
-			Price:      price,
+			Price:      row.Price,
 			BookingURL: config.BookingURL + "?teedate=" + dateClean,
 		})
 	}
@@ -0,0 +1,56 @@
+package platforms
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func parseQuick18Fixture(t *testing.T, name string) ([]quick18Row, error) {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	return parseQuick18Matrix(f)
+}
+
+func TestParseQuick18Matrix(t *testing.T) {
+	rows, err := parseQuick18Fixture(t, "quick18_matrix.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want []quick18Row = []quick18Row{
+		{Time: "7:04 AM", Course: "Riverside Back 9", Players: 4, Price: 24},
+		{Time: "12:40 PM", Course: "Riverside", Players: 2, Price: 1045.5},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(rows), len(want))
+	}
+	for i, row := range want {
+		if rows[i] != row {
+			t.Errorf("row %d = %+v, want %+v", i, rows[i], row)
+		}
+	}
+}
+
+func TestParseQuick18MatrixNoTeeTimes(t *testing.T) {
+	rows, err := parseQuick18Fixture(t, "quick18_empty.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 0 {
+		t.Errorf("got %d rows, want none", len(rows))
+	}
+}
+
+func TestParseQuick18MatrixChangedLayout(t *testing.T) {
+	for _, name := range []string{"quick18_changed.html", "quick18_missing_price.html"} {
+		_, err := parseQuick18Fixture(t, name)
+		if !errors.Is(err, ErrQuick18Layout) {
+			t.Errorf("%s: err = %v, want ErrQuick18Layout", name, err)
+		}
+	}
+}
@@ -1,16 +1,60 @@
 package platforms
 
-import "strings"
+import (
+	"strconv"
+	"strings"
+	"time"
+)
 
 // CourseEntry is a single bookable course in the global registry.
 type CourseEntry struct {
 	Key        string
+	Name       string // display name; entries covering several courses list them all
 	Metro      string
 	City       string
 	Fetch      func(date string) ([]DisplayTeeTime, error)
 	Match      func(name string) bool
 	BookingURL string
-	Enabled    bool // false = skip in metro tee-time fetches (e.g. Prophet/WAF-blocked)
+	Enabled    bool           // false = skip in metro tee-time fetches (e.g. Prophet/WAF-blocked)
+	Window     *BookingWindow // nil = release schedule unknown
+}
+
+// BookingWindow describes when a course releases inventory: tee times for a
+// date go on sale DaysAhead days earlier at ReleaseTime (24h "HH:MM",
+// course-local; empty means midnight). Loaded from data/booking_windows.json.
+type BookingWindow struct {
+	Key         string `json:"key"`
+	DaysAhead   int    `json:"daysAhead"`
+	ReleaseTime string `json:"releaseTime,omitempty"`
+	Timezone    string `json:"timezone,omitempty"`
+}
+
+// ReleaseAt returns when tee times for date (YYYY-MM-DD) become bookable.
+// ok is false when the course's booking window isn't known.
+func (e *CourseEntry) ReleaseAt(date string) (time.Time, bool) {
+	if e.Window == nil || e.Window.DaysAhead <= 0 {
+		return time.Time{}, false
+	}
+	var tz string = e.Window.Timezone
+	if tz == "" {
+		tz = "America/Denver"
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return time.Time{}, false
+	}
+	day, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var hour, minute int
+	if hh, mm, found := strings.Cut(e.Window.ReleaseTime, ":"); found {
+		hour, _ = strconv.Atoi(hh)
+		minute, _ = strconv.Atoi(mm)
+	}
+	var release time.Time = day.AddDate(0, 0, -e.Window.DaysAhead)
+	return time.Date(release.Year(), release.Month(), release.Day(), hour, minute, 0, 0, loc), true
 }
 
 // Registry holds every course across all platforms, populated during init().
@@ -27,6 +71,39 @@ func FindCourse(name string) (*CourseEntry, bool) {
 	return nil, false
 }
 
+// courseKeyAlias is a retired registry key: the part of a merged entry it
+// used to name, and the key of that entry.
+type courseKeyAlias struct {
+	entry  CourseEntry
+	parent string
+}
+
+var courseKeyAliases = map[string]courseKeyAlias{}
+
+// FindCourseByKey returns the entry with the given registry key. A retired
+// key returns an entry that matches only the course it used to name.
+func FindCourseByKey(key string) (*CourseEntry, bool) {
+	for i := range Registry {
+		if Registry[i].Key == key {
+			return &Registry[i], true
+		}
+	}
+	if alias, ok := courseKeyAliases[key]; ok {
+		var e CourseEntry = alias.entry
+		return &e, true
+	}
+	return nil, false
+}
+
+// CanonicalCourseKey returns the registry key that now fetches key's tee
+// times: key itself, or the entry a retired key was merged into.
+func CanonicalCourseKey(key string) string {
+	if alias, ok := courseKeyAliases[key]; ok {
+		return alias.parent
+	}
+	return key
+}
+
 // GetBaseCourse strips sub-course suffixes like " - Links" from a course name.
 func GetBaseCourse(name string) string {
 	var idx int = strings.Index(name, " - ")
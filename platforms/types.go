@@ -15,16 +15,70 @@ type DisplayTeeTime struct {
 	BookingURL string  `json:"bookingUrl"`
 }
 
+// Alert targets one Course (base display name), a list of CourseKeys, one or
+// more Cities (or the older single City) within Metro, or a whole Metro.
+// Dates are a single Date, a Date–DateTo range, or a Recurrence.
 type Alert struct {
-	ID         string `json:"id"`
-	Phone      string `json:"phone"`
-	Course     string `json:"course"`
-	Date       string `json:"date"`
-	StartTime  string `json:"startTime"`
-	EndTime    string `json:"endTime"`
-	MinPlayers int    `json:"minPlayers,omitempty"`
-	Holes      string `json:"holes,omitempty"`
-	Active     bool   `json:"active"`
-	CreatedAt  string `json:"createdAt"`
-	ConsentAt  string `json:"consentAt"`
+	ID            string      `json:"id"`
+	Phone         string      `json:"phone"`
+	Channel       string      `json:"channel,omitempty"` // sms (default), email, webhook or push
+	Contact       string      `json:"contact,omitempty"` // email address, webhook URL or push topic
+	Course        string      `json:"course,omitempty"`
+	CourseKeys    []string    `json:"courseKeys,omitempty"`
+	City          string      `json:"city,omitempty"`
+	Cities        []string    `json:"cities,omitempty"`
+	Metro         string      `json:"metro,omitempty"`
+	Date          string      `json:"date,omitempty"`
+	DateTo        string      `json:"dateTo,omitempty"`
+	Recurrence    *Recurrence `json:"recurrence,omitempty"`
+	StartTime     string      `json:"startTime"`
+	EndTime       string      `json:"endTime"`
+	MinPlayers    int         `json:"minPlayers,omitempty"`
+	Holes         string      `json:"holes,omitempty"`
+	MaxPrice      float64     `json:"maxPrice,omitempty"` // per player; 0 means no limit
+	Active        bool        `json:"active"`
+	NotifiedDates []string    `json:"notifiedDates,omitempty"`
+	OptedOut      bool        `json:"optedOut,omitempty"` // paused by an SMS STOP; START resumes it
+	Revision      int         `json:"revision,omitempty"` // bumped by each edit
+
+	// Continuous alerts stay armed and notify only about tee times that
+	// weren't in SeenTeeTimes, at most once per CooldownMinutes and
+	// MaxNotifications times in total.
+	Mode              string   `json:"mode,omitempty"`
+	CooldownMinutes   int      `json:"cooldownMinutes,omitempty"`
+	MaxNotifications  int      `json:"maxNotifications,omitempty"`
+	NotificationCount int      `json:"notificationCount,omitempty"`
+	LastNotifiedAt    string   `json:"lastNotifiedAt,omitempty"`
+	SeenTeeTimes      []string `json:"seenTeeTimes,omitempty"`
+
+	// NewOnly alerts ignore tee times that were already open and fire only
+	// on slots that opened up since the previous poll, e.g. cancellations.
+	NewOnly bool `json:"newOnly,omitempty"`
+
+	CreatedAt string   `json:"createdAt"`
+	ConsentAt string   `json:"consentAt"`
+	Consent   *Consent `json:"consent,omitempty"`
+	Pending   bool     `json:"pending,omitempty"` // waiting for phone verification
+}
+
+// Consent records the opt-in behind an alert for compliance.
+type Consent struct {
+	At          string `json:"at"`
+	IP          string `json:"ip"`
+	UserAgent   string `json:"userAgent"`
+	TextVersion string `json:"textVersion"`
+}
+
+const (
+	AlertModeOnce       = "once"
+	AlertModeContinuous = "continuous"
+)
+
+// Recurrence repeats an alert on the given weekdays (0 = Sunday) instead of
+// a single Date. DaysAhead limits how far out occurrences are checked; zero
+// means the course's booking window.
+type Recurrence struct {
+	Days      []int  `json:"days"`
+	EndDate   string `json:"endDate,omitempty"`
+	DaysAhead int    `json:"daysAhead,omitempty"`
 }
@@ -1,11 +1,43 @@
-var currentPhone = ""
+var token = localStorage.getItem("alertsToken") || ""
+var alertsById = {}
+var DAY_NAMES = ["Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"]
+
+function describeDates(a) {
+    if (!a.recurrence) {
+        if (a.dateTo && a.dateTo !== a.date) return a.date + " to " + a.dateTo
+        return a.date
+    }
+    var names = []
+    for (var i = 0; i < a.recurrence.days.length; i++) {
+        names.push(DAY_NAMES[a.recurrence.days[i]])
+    }
+    var desc = "Every " + names.join(", ")
+    if (a.recurrence.endDate) desc += " until " + a.recurrence.endDate
+    return desc
+}
+
+function describeTarget(a) {
+    if (a.course) return a.course
+    if (a.courseKeys && a.courseKeys.length > 0) {
+        return a.courseKeys.length === 1 ? a.courseKeys[0] : a.courseKeys.length + " courses"
+    }
+    if (a.cities && a.cities.length > 0) return "Any course in " + a.cities.join(" or ")
+    if (a.city) return "Any course in " + a.city
+    return "Any course in " + a.metro
+}
 
 async function loadAlerts() {
     var content = document.getElementById("alertsContent")
     var alertsList = document.getElementById("alertsList")
 
     try {
-        var response = await fetch("/api/alerts?phone=" + encodeURIComponent(currentPhone))
+        var response = await fetch("/api/alerts", {
+            headers: { "Authorization": "Bearer " + token }
+        })
+        if (response.status === 401) {
+            signOut("Your sign-in link has expired. Request a new one below.")
+            return
+        }
         if (!response.ok) {
             throw new Error("Server error: " + response.status)
         }
@@ -24,19 +56,43 @@ async function loadAlerts() {
             var a = alerts[i]
             var statusClass = a.active ? "alert-active" : "alert-inactive"
             var statusText = a.active ? "Active" : "Triggered"
+            if (a.mode === "continuous") {
+                statusText = a.active ? "Watching" : "Done"
+                if (a.notificationCount) statusText += " · " + a.notificationCount + " sent"
+            }
+            if (a.newOnly && a.active) statusText += " · new openings"
+            if (a.optedOut) statusText = "Paused (STOP)"
+            if (a.pending) statusText = "Awaiting verification"
 
             html += '<div class="alert-item">'
             html += '  <div class="alert-info">'
-            html += '    <div class="alert-course">' + a.course + '</div>'
-            html += '    <div class="alert-details">' + a.date + ' · ' + a.startTime + ' – ' + a.endTime + '</div>'
+            html += '    <div class="alert-course">' + describeTarget(a) + '</div>'
+            html += '    <div class="alert-details">' + describeDates(a) + ' · ' + a.startTime + ' – ' + a.endTime + (a.maxPrice ? ' · up to $' + a.maxPrice : '') + '</div>'
             html += '    <div class="alert-meta">Created ' + a.createdAt + '</div>'
             html += '  </div>'
             html += '  <div class="alert-actions">'
             html += '    <span class="alert-status ' + statusClass + '">' + statusText + '</span>'
+            html += '    <button class="btn-delete" onclick="showHistory(\'' + a.id + '\')">History</button>'
+            html += '    <button class="btn-delete" onclick="editAlert(\'' + a.id + '\')">Edit</button>'
             html += '    <button class="btn-delete" onclick="removeAlert(\'' + a.id + '\')">Delete</button>'
             html += '  </div>'
             html += '</div>'
+            html += '<div class="alert-history" id="history-' + a.id + '" style="display: none;"></div>'
+            html += '<div class="alert-edit" id="edit-' + a.id + '" style="display: none;">'
+            if (!a.recurrence) html += '  <label>Date <input type="date" class="edit-date" value="' + a.date + '"></label>'
+            html += '  <label>From <input type="text" class="edit-start" value="' + a.startTime + '"></label>'
+            html += '  <label>To <input type="text" class="edit-end" value="' + a.endTime + '"></label>'
+            html += '  <label>Min players <input type="number" class="edit-players" min="0" max="4" value="' + (a.minPlayers || 0) + '"></label>'
+            html += '  <label>Holes <select class="edit-holes">' + ["0", "9", "18"].map(function(h) {
+                return '<option value="' + h + '"' + ((a.holes || "0") === h ? " selected" : "") + '>' + (h === "0" ? "Any" : h) + '</option>'
+            }).join("") + '</select></label>'
+            html += '  <label>Max price <input type="number" class="edit-price" min="0" value="' + (a.maxPrice || 0) + '"></label>'
+            html += '  <button class="btn" onclick="saveAlert(\'' + a.id + '\')">Save</button>'
+            html += '  <span class="form-message edit-message"></span>'
+            html += '</div>'
         }
+        alertsById = {}
+        for (var j = 0; j < alerts.length; j++) alertsById[alerts[j].id] = alerts[j]
         html += '</div>'
 
         content.innerHTML = html
@@ -46,18 +102,169 @@ async function loadAlerts() {
     }
 }
 
-function lookupAlerts() {
-    var phone = document.getElementById("phone").value
-    if (!phone) return
+// Preferences only exist for phone numbers; the card stays hidden otherwise
+async function loadPrefs() {
+    try {
+        var response = await fetch("/api/prefs", {
+            headers: { "Authorization": "Bearer " + token }
+        })
+        if (!response.ok) return
+        var prefs = await response.json()
+        document.getElementById("prefQuietStart").value = prefs.quietStart || ""
+        document.getElementById("prefQuietEnd").value = prefs.quietEnd || ""
+        document.getElementById("prefMaxPerDay").value = prefs.maxPerDay || ""
+        document.getElementById("prefDigest").value = String(prefs.digestMinutes || 0)
+        document.getElementById("prefsCard").style.display = "block"
+    } catch (err) {
+        // Leave the card hidden
+    }
+}
 
-    currentPhone = phone
-    loadAlerts()
+async function savePrefs() {
+    var message = document.getElementById("prefsMessage")
+    var prefs = {
+        timezone: Intl.DateTimeFormat().resolvedOptions().timeZone,
+        quietStart: document.getElementById("prefQuietStart").value,
+        quietEnd: document.getElementById("prefQuietEnd").value,
+        maxPerDay: parseInt(document.getElementById("prefMaxPerDay").value) || 0,
+        digestMinutes: parseInt(document.getElementById("prefDigest").value) || 0
+    }
+    try {
+        var response = await fetch("/api/prefs", {
+            method: "POST",
+            headers: { "Content-Type": "application/json", "Authorization": "Bearer " + token },
+            body: JSON.stringify(prefs)
+        })
+        var data = await response.json()
+        if (!response.ok) {
+            message.textContent = data.error || "Failed to save."
+            message.className = "form-message form-error"
+            return
+        }
+        message.textContent = "✓ Settings saved."
+        message.className = "form-message form-success"
+    } catch (err) {
+        message.textContent = "Failed to save. Please try again."
+        message.className = "form-message form-error"
+    }
+}
+
+function signOut(msg) {
+    token = ""
+    localStorage.removeItem("alertsToken")
+    document.getElementById("alertsList").style.display = "none"
+    document.getElementById("prefsCard").style.display = "none"
+    var message = document.getElementById("lookupMessage")
+    message.textContent = msg
+    message.className = "form-message form-error"
+}
+
+async function lookupAlerts() {
+    var contact = document.getElementById("phone").value
+    if (!contact) return
+
+    var message = document.getElementById("lookupMessage")
+    try {
+        var response = await fetch("/api/alerts/link", {
+            method: "POST",
+            headers: { "Content-Type": "application/json" },
+            body: JSON.stringify({ contact: contact, metro: METRO })
+        })
+        var data = await response.json()
+        if (!response.ok) {
+            message.textContent = data.error || "Couldn't send a link."
+            message.className = "form-message form-error"
+            return
+        }
+        message.textContent = "✓ If you have alerts, a sign-in link is on its way."
+        message.className = "form-message form-success"
+    } catch (err) {
+        message.textContent = "Couldn't send a link. Please try again."
+        message.className = "form-message form-error"
+    }
+}
+
+var DELIVERY_LABELS = { sent: "Sent", failed: "Failed, retrying", dead: "Not delivered", dropped: "Skipped, times gone" }
+
+async function showHistory(id) {
+    var box = document.getElementById("history-" + id)
+    if (box.style.display !== "none") {
+        box.style.display = "none"
+        return
+    }
+    box.style.display = "block"
+    box.textContent = "Loading…"
+    try {
+        var response = await fetch("/api/alerts/history?id=" + encodeURIComponent(id), {
+            headers: { "Authorization": "Bearer " + token }
+        })
+        if (!response.ok) {
+            throw new Error("Server error: " + response.status)
+        }
+        var history = await response.json()
+        if (history.length === 0) {
+            box.textContent = "No notifications sent yet."
+            return
+        }
+        var html = ""
+        for (var i = history.length - 1; i >= 0; i--) {
+            var h = history[i]
+            var found = (h.matches || []).map(function(m) {
+                return m.course + " " + m.date + " " + m.time
+            }).join(", ")
+            html += '<div class="history-item">'
+            html += '  <span class="history-when">' + new Date(h.at).toLocaleString() + '</span>'
+            html += '  <span class="history-status history-' + h.status + '">' + (DELIVERY_LABELS[h.status] || h.status) + ' · ' + h.channel + '</span>'
+            if (found) html += '  <div class="history-matches">' + found + '</div>'
+            html += '</div>'
+        }
+        box.innerHTML = html
+    } catch (err) {
+        box.textContent = "Failed to load history."
+    }
+}
+
+function editAlert(id) {
+    var box = document.getElementById("edit-" + id)
+    box.style.display = box.style.display === "none" ? "flex" : "none"
+}
+
+async function saveAlert(id) {
+    var box = document.getElementById("edit-" + id)
+    var a = Object.assign({}, alertsById[id])
+    var dateInput = box.querySelector(".edit-date")
+    if (dateInput) a.date = dateInput.value
+    a.startTime = box.querySelector(".edit-start").value.trim()
+    a.endTime = box.querySelector(".edit-end").value.trim()
+    a.minPlayers = parseInt(box.querySelector(".edit-players").value) || 0
+    a.holes = box.querySelector(".edit-holes").value
+    a.maxPrice = parseFloat(box.querySelector(".edit-price").value) || 0
+
+    var message = box.querySelector(".edit-message")
+    try {
+        var response = await fetch("/api/alerts/update?id=" + encodeURIComponent(id), {
+            method: "POST",
+            headers: { "Content-Type": "application/json", "Authorization": "Bearer " + token },
+            body: JSON.stringify(a)
+        })
+        var data = await response.json()
+        if (!response.ok) {
+            message.textContent = data.error || "Failed to save."
+            message.className = "form-message form-error edit-message"
+            return
+        }
+        loadAlerts()
+    } catch (err) {
+        message.textContent = "Failed to save. Please try again."
+        message.className = "form-message form-error edit-message"
+    }
 }
 
 async function removeAlert(id) {
     try {
-        var response = await fetch("/api/alerts/delete?id=" + id + "&phone=" + encodeURIComponent(currentPhone), {
-            method: "POST"
+        var response = await fetch("/api/alerts/delete?id=" + encodeURIComponent(id), {
+            method: "POST",
+            headers: { "Authorization": "Bearer " + token }
         })
 
         if (!response.ok) {
@@ -71,6 +278,18 @@ async function removeAlert(id) {
 }
 
 document.getElementById("lookupBtn").addEventListener("click", lookupAlerts)
+document.getElementById("prefsBtn").addEventListener("click", savePrefs)
 document.getElementById("phone").addEventListener("keydown", function(e) {
     if (e.key === "Enter") lookupAlerts()
 })
+
+// Sign-in links carry the token in the fragment so it never reaches server logs
+if (location.hash.indexOf("#token=") === 0) {
+    token = decodeURIComponent(location.hash.slice(7))
+    localStorage.setItem("alertsToken", token)
+    history.replaceState(null, "", location.pathname)
+}
+if (token) {
+    loadAlerts()
+    loadPrefs()
+}
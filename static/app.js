@@ -236,33 +236,161 @@ function updateAlertSection() {
     var alertContext = document.getElementById("alertContext")
     var message = document.getElementById("message")
 
-    if (courseFilter === "") {
+    if (date === "") {
         alertPrompt.style.display = "block"
         alertForm.style.display = "none"
-    } else {
-        alertPrompt.style.display = "none"
-        alertForm.style.display = "block"
+        return
+    }
+    alertPrompt.style.display = "none"
+    alertForm.style.display = "block"
+    if (courseFilter !== "") {
         alertContext.textContent = "Get a text when a tee time opens at " + courseFilter + " on " + date + "."
-        message.textContent = ""
+    } else {
+        // No course picked: watch every course in the city or metro
+        var where = document.getElementById("city").value || METRO_NAME
+        alertContext.textContent = "Get a text with the best tee times at any course in " + where + " on " + date + "."
+    }
+    message.textContent = ""
+    message.className = "form-message"
+}
+
+// syncAlertFilters starts the alert form from the list's filters, so an
+// alert watches for what the user is already looking at.
+function syncAlertFilters() {
+    document.getElementById("alertOpenings").value = document.getElementById("openings").value || "0"
+    document.getElementById("alertHoles").value = document.getElementById("holes").value || "0"
+    var setHour = function(id, hour) {
+        var label = (hour % 12 || 12) + ":00 " + (hour < 12 ? "AM" : "PM")
+        var select = document.getElementById(id)
+        for (var i = 0; i < select.options.length; i++) {
+            if (select.options[i].value === label) select.value = label
+        }
+    }
+    setHour("startTime", parseInt(document.getElementById("timeFrom").value))
+    setHour("endTime", parseInt(document.getElementById("timeTo").value))
+}
+
+var CHANNEL_INPUTS = {
+    sms: { label: "Phone Number", type: "tel", placeholder: "(303) 555-1234" },
+    email: { label: "Email", type: "email", placeholder: "you@example.com" },
+    push: { label: "ntfy Topic", type: "text", placeholder: "my-tee-times" },
+    webhook: { label: "Webhook URL", type: "url", placeholder: "https://example.com/hook" }
+}
+
+function updateAlertChannel() {
+    var input = CHANNEL_INPUTS[document.getElementById("alertChannel").value]
+    var field = document.getElementById("phone")
+    document.getElementById("phoneLabel").textContent = input.label
+    field.type = input.type
+    field.placeholder = input.placeholder
+    field.value = ""
+}
+
+// alertFilters reads what the alert watches for from the form, in the shape
+// /api/alerts/create and /api/alerts/preview expect.
+function alertFilters() {
+    var date = document.getElementById("date").value
+    var recurrence = null
+    var dateTo = ""
+    var repeat = document.getElementById("alertRepeat").value
+    if (repeat === "2" || repeat === "3") {
+        var last = new Date(date + "T12:00:00")
+        last.setDate(last.getDate() + parseInt(repeat) - 1)
+        dateTo = last.getFullYear() + "-" + String(last.getMonth() + 1).padStart(2, "0") + "-" + String(last.getDate()).padStart(2, "0")
+    } else if (repeat === "weekly") {
+        recurrence = { days: [new Date(date + "T12:00:00").getDay()] }
+    } else if (repeat === "weekdays") {
+        recurrence = { days: [1, 2, 3, 4, 5] }
+    } else if (repeat === "weekends") {
+        recurrence = { days: [0, 6] }
+    }
+
+    // "New openings only" keeps watching, but skips times already open now
+    var mode = document.getElementById("alertMode").value
+    var newOnly = mode === "new"
+    if (newOnly) mode = "continuous"
+
+    var course = document.getElementById("course").value
+    var city = document.getElementById("city").value
+
+    return {
+        course: course,
+        metro: course === "" ? METRO : "",
+        cities: course === "" && city !== "" ? [city] : [],
+        date: date,
+        dateTo: dateTo,
+        startTime: document.getElementById("startTime").value,
+        endTime: document.getElementById("endTime").value,
+        minPlayers: parseInt(document.getElementById("alertOpenings").value) || 0,
+        holes: document.getElementById("alertHoles").value,
+        maxPrice: parseFloat(document.getElementById("alertMaxPrice").value) || 0,
+        recurrence: recurrence,
+        mode: mode,
+        newOnly: newOnly
+    }
+}
+
+var FILTER_LABELS = { openings: "full", players: "too few spots", holes: "wrong holes", unpriced: "no listed price", price: "over your price", time: "outside your times" }
+
+async function previewAlert() {
+    var message = document.getElementById("message")
+    try {
+        var response = await fetch("/api/alerts/preview", {
+            method: "POST",
+            headers: { "Content-Type": "application/json" },
+            body: JSON.stringify(alertFilters())
+        })
+        var data = await response.json()
+        if (!response.ok) {
+            message.textContent = data.error || "Couldn't preview this alert."
+            message.className = "form-message form-error"
+            return
+        }
+
+        var parts = []
+        if (data.matches.length > 0) {
+            parts.push(data.matches.length + " tee time(s) match right now — first at " + data.matches[0].time + " on " + data.matches[0].date)
+        } else {
+            parts.push("Nothing matches right now")
+        }
+        var excluded = []
+        for (var filter in data.excludedCounts) {
+            excluded.push(data.excludedCounts[filter] + " " + (FILTER_LABELS[filter] || filter))
+        }
+        if (excluded.length > 0) parts.push("Excluded: " + excluded.join(", "))
+        if (data.unreleased && data.unreleased.length > 0) parts.push("Not on sale yet: " + data.unreleased.join(", "))
+        if (data.typical.sheets > 0) {
+            parts.push("Openings came up in this window on " + data.typical.sheetsWithOpenings + " of " + data.typical.sheets + " tee sheets in the last 8 weeks")
+        }
+        if (data.typical.recentOpenings > 0) {
+            parts.push(data.typical.recentOpenings + " opened up in the last day")
+        }
+        message.textContent = parts.join(" · ")
         message.className = "form-message"
+    } catch (err) {
+        message.textContent = "Couldn't preview this alert. Please try again."
+        message.className = "form-message form-error"
     }
 }
 
 async function createAlert() {
+    var channel = document.getElementById("alertChannel").value
     var phone = document.getElementById("phone").value
-    var course = document.getElementById("course").value
-    var date = document.getElementById("date").value
-    var startTime = document.getElementById("startTime").value
-    var endTime = document.getElementById("endTime").value
     var message = document.getElementById("message")
 
     if (!phone) {
-        message.textContent = "Please enter your phone number."
+        message.textContent = "Please enter your " + CHANNEL_INPUTS[channel].label.toLowerCase() + "."
         message.className = "form-message form-error"
         return
     }
 
-    if (!document.getElementById("consentCheck").checked) {
+    var contact = ""
+    if (channel !== "sms") {
+        contact = phone
+        phone = ""
+    }
+
+    if (channel === "sms" && !document.getElementById("consentCheck").checked) {
         message.textContent = "Please agree to receive SMS alerts."
         message.className = "form-message form-error"
         return
@@ -273,19 +401,19 @@ async function createAlert() {
     btn.textContent = "Creating..."
 
     try {
+        // A saved sign-in for this number skips the verification code
+        var headers = { "Content-Type": "application/json" }
+        var savedToken = localStorage.getItem("alertsToken")
+        if (savedToken) headers["Authorization"] = "Bearer " + savedToken
         var response = await fetch("/api/alerts/create", {
             method: "POST",
-            headers: { "Content-Type": "application/json" },
-            body: JSON.stringify({
+            headers: headers,
+            body: JSON.stringify(Object.assign(alertFilters(), {
                 phone: phone,
-                course: course,
-                date: date,
-                startTime: startTime,
-                endTime: endTime,
-                minPlayers: parseInt(document.getElementById("alertOpenings").value) || 0,
-                holes: document.getElementById("alertHoles").value,
+                channel: channel,
+                contact: contact,
                 consent: true
-            })
+            }))
         })
 
         var data = await response.json()
@@ -293,8 +421,15 @@ async function createAlert() {
         if (!response.ok) {
             message.textContent = data.error || "Failed to create alert."
             message.className = "form-message form-error"
+        } else if (data.pending) {
+            if (pendingPhone !== data.phone) pendingAlertIds = []
+            pendingPhone = data.phone
+            pendingAlertIds.push(data.id)
+            message.textContent = "Almost done! Enter the 6-digit code we just texted you to turn this alert on."
+            message.className = "form-message form-success"
+            document.getElementById("verifyBox").style.display = "flex"
         } else {
-            message.textContent = "✓ Alert created! We'll text you when a tee time opens up."
+            message.textContent = "✓ Alert created! We'll let you know when a tee time opens up."
             message.className = "form-message form-success"
             document.getElementById("phone").value = ""
         }
@@ -307,14 +442,61 @@ async function createAlert() {
     btn.textContent = "Create Alert"
 }
 
+var pendingPhone = ""
+var pendingAlertIds = []
+
+async function verifyPhone() {
+    var message = document.getElementById("message")
+    try {
+        var response = await fetch("/api/phone/verify", {
+            method: "POST",
+            headers: { "Content-Type": "application/json" },
+            body: JSON.stringify({ phone: pendingPhone, code: document.getElementById("verifyCode").value.trim(), alertIds: pendingAlertIds })
+        })
+        var data = await response.json()
+        if (!response.ok) {
+            message.textContent = data.error || "Verification failed."
+            message.className = "form-message form-error"
+            return
+        }
+        if (data.token) localStorage.setItem("alertsToken", data.token)
+        pendingAlertIds = []
+        message.textContent = "✓ Phone verified! Your alert is on — we'll text you when a tee time opens up."
+        message.className = "form-message form-success"
+        document.getElementById("verifyBox").style.display = "none"
+        document.getElementById("verifyCode").value = ""
+        document.getElementById("phone").value = ""
+    } catch (err) {
+        message.textContent = "Verification failed. Please try again."
+        message.className = "form-message form-error"
+    }
+}
+
+async function resendCode() {
+    var message = document.getElementById("message")
+    var response = await fetch("/api/phone/code", {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({ phone: pendingPhone })
+    })
+    var data = await response.json()
+    message.textContent = response.ok ? "A new code is on its way." : (data.error || "Couldn't send a code.")
+    message.className = response.ok ? "form-message form-success" : "form-message form-error"
+}
+
 document.getElementById("date").addEventListener("change", fetchTimes)
 document.getElementById("course").addEventListener("change", function() { updateCityFilter(); displayTimes() })
 document.getElementById("city").addEventListener("change", function() { updateCourseFilter(); displayTimes() })
 document.getElementById("timeFrom").addEventListener("input", updateSlider)
 document.getElementById("timeTo").addEventListener("input", updateSlider)
-document.getElementById("openings").addEventListener("change", displayTimes)
-document.getElementById("holes").addEventListener("change", displayTimes)
+document.getElementById("timeFrom").addEventListener("change", syncAlertFilters)
+document.getElementById("timeTo").addEventListener("change", syncAlertFilters)
+document.getElementById("openings").addEventListener("change", function() { syncAlertFilters(); displayTimes() })
+document.getElementById("holes").addEventListener("change", function() { syncAlertFilters(); displayTimes() })
 document.getElementById("createBtn").addEventListener("click", createAlert)
+document.getElementById("previewBtn").addEventListener("click", previewAlert)
+document.getElementById("verifyBtn").addEventListener("click", verifyPhone)
+document.getElementById("resendBtn").addEventListener("click", resendCode)
 
 updateSlider()
 fetchTimes()